@@ -0,0 +1,337 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	istiosecurityv1beta1 "istio.io/api/security/v1beta1"
+	istiotypev1beta1 "istio.io/api/type/v1beta1"
+	istiosecurityclientv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+)
+
+type AuthorizationPolicy struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewAuthorizationPolicy(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *AuthorizationPolicy {
+	d := &AuthorizationPolicy{
+		plus:   plus,
+		logger: logger.WithValues("Own", "AuthorizationPolicy"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *AuthorizationPolicy) Apply() error {
+	obj, err := r.generateGatewayAccess()
+	if err != nil {
+		return err
+	}
+	if err := r.apply(r.plus.GetName()+"-gateway-access", obj); err != nil {
+		return err
+	}
+
+	obj, err = r.generateMeshAccess()
+	if err != nil {
+		return err
+	}
+	return r.apply(r.plus.GetName()+"-access", obj)
+}
+
+// apply 创建或更新，obj 为 nil 时删除已经存在的资源
+func (r *AuthorizationPolicy) apply(name string, obj *istiosecurityclientv1beta1.AuthorizationPolicy) error {
+	exist, found, err := r.exist(name)
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!", "Name", name)
+			if err := r.client.Delete(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!", "Name", name)
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 AuthorizationPolicy 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("authorization policy %s already exists and is not controlled by plus %s", name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Selector, found.Spec.Selector) ||
+		!reflect.DeepEqual(obj.Spec.Rules, found.Spec.Rules) ||
+		!reflect.DeepEqual(obj.Spec.Action, found.Spec.Action) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!", "Name", name)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *AuthorizationPolicy) UpdateStatus() error {
+	return nil
+}
+
+func (r *AuthorizationPolicy) Type() string {
+	return "AuthorizationPolicy"
+}
+
+// generateGatewayAccess 生成网关访问控制策略。
+// 使用 DENY 策略实现白名单，这样不会影响网格内其他服务的调用
+func (r *AuthorizationPolicy) generateGatewayAccess() (*istiosecurityclientv1beta1.AuthorizationPolicy, error) {
+	if r.plus.Spec.Gateway == nil || r.plus.Spec.Gateway.Access == nil {
+		return nil, nil
+	}
+	access := r.plus.Spec.Gateway.Access
+	principals := access.GetGatewayPrincipals()
+
+	rules := make([]*istiosecurityv1beta1.Rule, 0)
+	if len(access.DenyIPs) > 0 {
+		rules = append(rules, &istiosecurityv1beta1.Rule{
+			From: []*istiosecurityv1beta1.Rule_From{{
+				Source: &istiosecurityv1beta1.Source{
+					Principals:     principals,
+					RemoteIpBlocks: access.DenyIPs,
+				},
+			}},
+		})
+	}
+
+	if len(access.AllowIPs) > 0 {
+		rules = append(rules, &istiosecurityv1beta1.Rule{
+			From: []*istiosecurityv1beta1.Rule_From{{
+				Source: &istiosecurityv1beta1.Source{
+					Principals:        principals,
+					NotRemoteIpBlocks: access.AllowIPs,
+				},
+			}},
+		})
+	}
+
+	if access.Jwt != nil {
+		rules = append(rules, r.generateJwtRules(access.Jwt, principals)...)
+	}
+
+	if len(rules) == 0 {
+		return nil, nil
+	}
+
+	policy := &istiosecurityclientv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName() + "-gateway-access",
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istiosecurityv1beta1.AuthorizationPolicy{
+			Selector: r.generateSelector(),
+			Action:   istiosecurityv1beta1.AuthorizationPolicy_DENY,
+			Rules:    rules,
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, policy, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return policy, nil
+}
+
+// generateJwtRules 拒绝没有 token 或者 claims 不匹配的请求
+func (r *AuthorizationPolicy) generateJwtRules(jwt *v1.PlusGatewayJwt, principals []string) []*istiosecurityv1beta1.Rule {
+	rules := make([]*istiosecurityv1beta1.Rule, 0, len(jwt.Rules))
+	for _, jwtRule := range jwt.Rules {
+		var to []*istiosecurityv1beta1.Rule_To
+		if len(jwtRule.Paths) > 0 || len(jwtRule.Methods) > 0 {
+			to = []*istiosecurityv1beta1.Rule_To{{
+				Operation: &istiosecurityv1beta1.Operation{
+					Paths:   jwtRule.Paths,
+					Methods: jwtRule.Methods,
+				},
+			}}
+		}
+
+		rules = append(rules, &istiosecurityv1beta1.Rule{
+			From: []*istiosecurityv1beta1.Rule_From{{
+				Source: &istiosecurityv1beta1.Source{
+					Principals:           principals,
+					NotRequestPrincipals: []string{"*"},
+				},
+			}},
+			To: to,
+		})
+
+		claims := make([]string, 0, len(jwtRule.Claims))
+		for claim := range jwtRule.Claims {
+			claims = append(claims, claim)
+		}
+		sort.Strings(claims)
+		for _, claim := range claims {
+			rules = append(rules, &istiosecurityv1beta1.Rule{
+				From: []*istiosecurityv1beta1.Rule_From{{
+					Source: &istiosecurityv1beta1.Source{
+						Principals: principals,
+					},
+				}},
+				To: to,
+				When: []*istiosecurityv1beta1.Condition{{
+					Key:       fmt.Sprintf("request.auth.claims[%s]", claim),
+					NotValues: jwtRule.Claims[claim],
+				}},
+			})
+		}
+	}
+	return rules
+}
+
+// generateMeshAccess 生成网格内的访问控制策略，使用 ALLOW 策略，没有命中规则的请求会被拒绝。
+// 配置了网关时允许来自网关的请求，网关的黑白名单由 generateGatewayAccess 控制
+func (r *AuthorizationPolicy) generateMeshAccess() (*istiosecurityclientv1beta1.AuthorizationPolicy, error) {
+	if r.plus.Spec.Access == nil || len(r.plus.Spec.Access.AllowFrom) == 0 {
+		return nil, nil
+	}
+
+	rules := make([]*istiosecurityv1beta1.Rule, 0, len(r.plus.Spec.Access.AllowFrom)+1)
+	if r.plus.Spec.Gateway != nil {
+		principals := []string{v1.DefaultGatewayPrincipal}
+		if r.plus.Spec.Gateway.Access != nil {
+			principals = r.plus.Spec.Gateway.Access.GetGatewayPrincipals()
+		}
+		rules = append(rules, &istiosecurityv1beta1.Rule{
+			From: []*istiosecurityv1beta1.Rule_From{{
+				Source: &istiosecurityv1beta1.Source{
+					Principals: principals,
+				},
+			}},
+		})
+	}
+
+	for _, source := range r.plus.Spec.Access.AllowFrom {
+		rule, err := r.generateSourceRule(source)
+		if err != nil {
+			return nil, err
+		}
+		if rule != nil {
+			rules = append(rules, rule)
+		}
+	}
+
+	policy := &istiosecurityclientv1beta1.AuthorizationPolicy{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName() + "-access",
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istiosecurityv1beta1.AuthorizationPolicy{
+			Selector: r.generateSelector(),
+			Action:   istiosecurityv1beta1.AuthorizationPolicy_ALLOW,
+			Rules:    rules,
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, policy, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return policy, nil
+}
+
+// generateSourceRule 命名空间和身份分别放在不同的 from 中，满足其一即可。
+// 引用的 Plus 不存在时忽略，所有来源都不存在时返回 nil，不能生成没有 from 的规则(会允许所有来源)
+func (r *AuthorizationPolicy) generateSourceRule(source *v1.PlusAccessSource) (*istiosecurityv1beta1.Rule, error) {
+	principals := make([]string, 0, len(source.ServiceAccounts)+len(source.Pluses))
+	for _, sa := range source.ServiceAccounts {
+		namespace, name := v1.ParseNamespacedName(r.plus.GetNamespace(), sa)
+		principals = append(principals, v1.GeneratePrincipal(namespace, name))
+	}
+
+	for _, p := range source.Pluses {
+		namespace, name := v1.ParseNamespacedName(r.plus.GetNamespace(), p)
+		plus := &v1.Plus{}
+		err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: namespace}, plus)
+		if err != nil {
+			if errors.IsNotFound(err) {
+				r.logger.Info("Plus in allowFrom not found, ignore it", "Plus", p)
+				continue
+			}
+			return nil, err
+		}
+		// 使用命名空间 default ServiceAccount 的版本没有独立的身份，无法单独授权
+		if len(plus.GeneratePrincipals()) == 0 {
+			r.logger.Info("Plus in allowFrom has no dedicated service account, ignore it", "Plus", p)
+			continue
+		}
+		principals = append(principals, plus.GeneratePrincipals()...)
+	}
+
+	from := make([]*istiosecurityv1beta1.Rule_From, 0, 2)
+	if len(source.Namespaces) > 0 {
+		from = append(from, &istiosecurityv1beta1.Rule_From{
+			Source: &istiosecurityv1beta1.Source{Namespaces: source.Namespaces},
+		})
+	}
+	if len(principals) > 0 {
+		from = append(from, &istiosecurityv1beta1.Rule_From{
+			Source: &istiosecurityv1beta1.Source{Principals: principals},
+		})
+	}
+	if len(from) == 0 {
+		return nil, nil
+	}
+
+	rule := &istiosecurityv1beta1.Rule{From: from}
+	if len(source.Paths) > 0 || len(source.Methods) > 0 {
+		rule.To = []*istiosecurityv1beta1.Rule_To{{
+			Operation: &istiosecurityv1beta1.Operation{
+				Paths:   source.Paths,
+				Methods: source.Methods,
+			},
+		}}
+	}
+	return rule, nil
+}
+
+func (r *AuthorizationPolicy) generateSelector() *istiotypev1beta1.WorkloadSelector {
+	return &istiotypev1beta1.WorkloadSelector{
+		MatchLabels: r.plus.GenerateLabels(),
+	}
+}
+
+func (r *AuthorizationPolicy) exist(name string) (bool, *istiosecurityclientv1beta1.AuthorizationPolicy, error) {
+	found := &istiosecurityclientv1beta1.AuthorizationPolicy{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
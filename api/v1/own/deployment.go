@@ -2,14 +2,17 @@ package own
 
 import (
 	"context"
+	"fmt"
 	"github.com/go-test/deep"
 	"reflect"
+	"strings"
 
 	v1 "clusterplus.io/clusterplus/api/v1"
 	"github.com/go-logr/logr"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
@@ -109,15 +112,32 @@ func (r *Deployment) UpdateStatus() error {
 		r.plus.Status.AvailableReplicas = make(map[string]int32, 0)
 	}
 
+	stuck := make([]string, 0)
 	for _, app := range r.plus.Spec.Apps {
 		found := &appsv1.Deployment{}
 		err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetAppName(app), Namespace: r.plus.GetNamespace()}, found)
 		if err != nil {
-			return nil
+			continue
 		}
 		r.plus.Status.AvailableReplicas[app.Version] = found.Status.AvailableReplicas
+		if exceeded, message := v1.IsProgressDeadlineExceeded(found); exceeded {
+			stuck = append(stuck, fmt.Sprintf("%s: %s", app.Version, message))
+		}
 	}
 
+	// 更新超时的事件由 controller 在 condition 变化时记录
+	condition := metav1.Condition{
+		Type:               v1.ConditionRolloutStuck,
+		Status:             metav1.ConditionFalse,
+		Reason:             "Progressing",
+		ObservedGeneration: r.plus.GetGeneration(),
+	}
+	if len(stuck) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = v1.ReasonProgressDeadlineExceeded
+		condition.Message = strings.Join(stuck, "; ")
+	}
+	meta.SetStatusCondition(&r.plus.Status.Conditions, condition)
 	return nil
 }
 
@@ -127,8 +147,6 @@ func (r *Deployment) Type() string {
 
 func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 	hostPathType := corev1.HostPathType("")
-	progressDeadlineSeconds := int32(600)
-	revisionHistoryLimit := int32(10)
 
 	logPath := "/app/logs/"
 	logsKey := r.plus.GetName() + "-logs"
@@ -145,8 +163,9 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 			Annotations: r.plus.Annotations,
 		},
 		Spec: appsv1.DeploymentSpec{
-			ProgressDeadlineSeconds: &progressDeadlineSeconds,
-			RevisionHistoryLimit:    &revisionHistoryLimit,
+			ProgressDeadlineSeconds: app.Rollout.GetProgressDeadlineSeconds(),
+			RevisionHistoryLimit:    app.Rollout.GetRevisionHistoryLimit(),
+			MinReadySeconds:         app.Rollout.GetMinReadySeconds(),
 			Replicas:                r.buildReplicas(app),
 			Selector: &metav1.LabelSelector{
 				MatchLabels: r.plus.GenerateAppLabels(app),
@@ -158,7 +177,8 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 					Annotations: r.buildAnnotations(app),
 				},
 				Spec: corev1.PodSpec{
-					HostAliases: app.HostAliases,
+					HostAliases:        app.HostAliases,
+					ServiceAccountName: r.plus.GetServiceAccountName(app),
 					ImagePullSecrets: []corev1.LocalObjectReference{
 						{Name: app.ImagePullSecrets},
 					},
@@ -169,6 +189,7 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 					SchedulerName:                 "default-scheduler",
 					TerminationGracePeriodSeconds: r.buildTerminationGracePeriodSeconds(app),
 					NodeSelector:                  app.NodeSelector,
+					ReadinessGates:                r.buildReadinessGates(),
 					Containers: []corev1.Container{{
 						Image:                    r.plus.GetAppImage(app),
 						ImagePullPolicy:          corev1.PullAlways,
@@ -178,6 +199,7 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 						Env:                      r.buildEnv(app.Env),
 						Command:                  nil,
 						ReadinessProbe:           r.buildReadinessProbe(app),
+						Lifecycle:                r.buildLifecycle(app),
 						LivenessProbe:            r.buildLivelinessProbe(app),
 						TerminationMessagePath:   "/dev/termination-log",
 						TerminationMessagePolicy: "File",
@@ -192,7 +214,7 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 							},
 						},
 					}},
-					Affinity: app.Affinity,
+					Affinity: r.plus.GetAffinity(app),
 					Volumes: []corev1.Volume{{
 						Name: "tz-config",
 						VolumeSource: corev1.VolumeSource{
@@ -207,7 +229,8 @@ func (r *Deployment) generate(app *v1.PlusApp) (*appsv1.Deployment, error) {
 							EmptyDir: &corev1.EmptyDirVolumeSource{},
 						},
 					}},
-					Tolerations: app.Tolerations,
+					Tolerations:               app.Tolerations,
+					TopologySpreadConstraints: r.plus.GetTopologySpreadConstraints(app),
 				},
 			},
 		},
@@ -259,25 +282,51 @@ func (r *Deployment) buildStrategy(app *v1.PlusApp) appsv1.DeploymentStrategy {
 		}
 	}
 
-	maxSurge := intstr.FromString("25%")
-	maxUnavailable := intstr.FromString("25%")
 	return appsv1.DeploymentStrategy{
 		Type: appsv1.RollingUpdateDeploymentStrategyType,
 		RollingUpdate: &appsv1.RollingUpdateDeployment{
-			MaxSurge:       &maxSurge,
-			MaxUnavailable: &maxUnavailable,
+			MaxSurge:       app.Rollout.GetMaxSurge(),
+			MaxUnavailable: app.Rollout.GetMaxUnavailable(),
 		},
 	}
 }
 
+// buildReadinessGates 依赖的 Plus Ready 之前 Pod 不会 Ready，由 controller 设置 Pod 的 condition
+func (r *Deployment) buildReadinessGates() []corev1.PodReadinessGate {
+	if !r.plus.IsWaitReady() {
+		return nil
+	}
+	return []corev1.PodReadinessGate{{ConditionType: v1.DependenciesReadinessGate}}
+}
+
 func (r *Deployment) buildTerminationGracePeriodSeconds(app *v1.PlusApp) *int64 {
-	t := int64(30)
+	t := int64(v1.DefaultTerminationGracePeriodSeconds)
 	if app.TerminationGracePeriodSeconds > 0 {
 		t = app.TerminationGracePeriodSeconds
+	} else if streaming := r.plus.GetStreaming(app); streaming != nil {
+		// preStop 等待的时间不计入应用退出的时间
+		t += int64(streaming.GetDrainDuration().Seconds())
 	}
 	return &t
 }
 
+// buildLifecycle 长连接模式下 preStop 等待一段时间，实例从负载均衡中摘除后客户端有时间重连到新实例。
+// 当前 k8s 版本没有 sleep 类型的 lifecycle，只能通过 sh -c sleep 等待，镜像中必须有 sh，
+// distroless 等没有 shell 的镜像 preStop 会失败，需要把 drainDuration 设置为 0s 关闭
+func (r *Deployment) buildLifecycle(app *v1.PlusApp) *corev1.Lifecycle {
+	streaming := r.plus.GetStreaming(app)
+	if streaming == nil || streaming.GetDrainDuration() == 0 {
+		return nil
+	}
+	return &corev1.Lifecycle{
+		PreStop: &corev1.LifecycleHandler{
+			Exec: &corev1.ExecAction{
+				Command: []string{"sh", "-c", fmt.Sprintf("sleep %d", int64(streaming.GetDrainDuration().Seconds()))},
+			},
+		},
+	}
+}
+
 func (r *Deployment) buildResources(res corev1.ResourceRequirements) corev1.ResourceRequirements {
 	return res
 }
@@ -364,5 +413,12 @@ func (r *Deployment) buildAnnotations(app *v1.PlusApp) map[string]string {
 		m["sidecar.istio.io/proxyMemoryLimit"] = app.ProxyResources.Limits.Memory().String()
 	}
 
+	// sidecar 默认只等待 5s 就退出，长连接模式下和应用的 preStop 保持一致，templateAnnotations 中配置的优先
+	if streaming := r.plus.GetStreaming(app); streaming != nil && streaming.GetDrainDuration() > 0 {
+		if _, ok := m["proxy.istio.io/config"]; !ok {
+			m["proxy.istio.io/config"] = fmt.Sprintf("terminationDrainDuration: %ds", int64(streaming.GetDrainDuration().Seconds()))
+		}
+	}
+
 	return m
 }
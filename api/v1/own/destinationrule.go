@@ -38,14 +38,35 @@ func (r *DestinationRule) Apply() error {
 	if err != nil {
 		return err
 	}
+	if err := r.apply(obj); err != nil {
+		return err
+	}
+
+	names := map[string]bool{obj.Name: true}
+	for _, egress := range r.plus.Spec.Egress {
+		if egress.Tls == nil {
+			continue
+		}
+		obj, err := r.generateEgress(egress)
+		if err != nil {
+			return err
+		}
+		if err := r.apply(obj); err != nil {
+			return err
+		}
+		names[obj.Name] = true
+	}
+	return r.deleteUnused(names)
+}
 
-	exist, found, err := r.exist()
+func (r *DestinationRule) apply(obj *istioclientapiv1.DestinationRule) error {
+	exist, found, err := r.exist(obj.Name)
 	if err != nil {
 		return err
 	}
 
 	if !exist {
-		r.logger.Info("Not found, create it!")
+		r.logger.Info("Not found, create it!", "Name", obj.Name)
 		if err := r.client.Create(context.TODO(), obj); err != nil {
 			return err
 		}
@@ -58,7 +79,7 @@ func (r *DestinationRule) Apply() error {
 			!reflect.DeepEqual(obj.Spec.Subsets, found.Spec.Subsets) ||
 			!reflect.DeepEqual(obj.Spec.ExportTo, found.Spec.ExportTo) ||
 			!reflect.DeepEqual(obj.Spec.WorkloadSelector, found.Spec.WorkloadSelector) {
-			r.logger.Info("Updating!")
+			r.logger.Info("Updating!", "Name", obj.Name)
 			if err := r.client.Update(context.TODO(), obj); err != nil {
 				return err
 			}
@@ -68,6 +89,24 @@ func (r *DestinationRule) Apply() error {
 
 }
 
+// deleteUnused 删除移除或者不再发起 TLS 的外部依赖对应的 DestinationRule，只删除由该 Plus 创建的
+func (r *DestinationRule) deleteUnused(names map[string]bool) error {
+	list := &istioclientapiv1.DestinationRuleList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(r.plus.GetNamespace()), client.MatchingLabels{"plus": r.plus.GetName()}); err != nil {
+		return err
+	}
+	for _, found := range list.Items {
+		if names[found.Name] || !metav1.IsControlledBy(found, r.plus) {
+			continue
+		}
+		r.logger.Info("Not required, delete it!", "Name", found.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *DestinationRule) UpdateStatus() error {
 	return nil
 }
@@ -82,7 +121,7 @@ func (r *DestinationRule) generate() (*istioclientapiv1.DestinationRule, error)
 		subsets = append(subsets, &istioapiv1.Subset{
 			Name:          r.plus.GetAppName(app),
 			Labels:        r.plus.GenerateAppLabels(app),
-			TrafficPolicy: nil,
+			TrafficPolicy: r.generateSubsetTrafficPolicy(app),
 		})
 	}
 	ds := &istioclientapiv1.DestinationRule{
@@ -109,45 +148,113 @@ func (r *DestinationRule) generate() (*istioclientapiv1.DestinationRule, error)
 	return ds, nil
 }
 
+// generateEgress 外部依赖的 DestinationRule，sidecar 将程序的明文请求升级为 TLS
+func (r *DestinationRule) generateEgress(egress *v1.PlusEgress) (*istioclientapiv1.DestinationRule, error) {
+	ds := &istioclientapiv1.DestinationRule{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      egress.GetResourceName(r.plus),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istioapiv1.DestinationRule{
+			Host: egress.Host,
+			TrafficPolicy: &istioapiv1.TrafficPolicy{
+				Tls: egress.Tls.GetClientTLSSettings(egress.Host),
+			},
+			ExportTo: []string{"."},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, ds, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return ds, nil
+}
+
+// generateSubsetTrafficPolicy 生成版本级别的流量策略，没有覆盖配置时沿用 DestinationRule 级别的策略
+func (r *DestinationRule) generateSubsetTrafficPolicy(app *v1.PlusApp) *istioapiv1.TrafficPolicy {
+	if app.Policy == nil {
+		return nil
+	}
+
+	policy := &istioapiv1.TrafficPolicy{}
+	if app.Policy.LoadBalancer != nil {
+		// 版本的负载均衡配置会整体覆盖，需要保留地域负载均衡
+		policy.LoadBalancer = app.Policy.LoadBalancer.GetLoadBalancerSettings()
+		policy.LoadBalancer.LocalityLbSetting = r.plus.GetLocality().GetLocalityLbSetting()
+	}
+	if app.Policy.MaxRequest > 0 || app.Policy.ConnectionPool != nil {
+		policy.ConnectionPool = app.Policy.ConnectionPool.GetConnectionPoolSettings(app.Policy.MaxRequest)
+	}
+	if app.Policy.OutlierDetection != nil {
+		policy.OutlierDetection = r.buildOutlierDetection(app.Policy.OutlierDetection)
+	}
+	if app.Policy.Streaming != nil {
+		if policy.ConnectionPool == nil {
+			policy.ConnectionPool = r.generateBaseConnectionPoolSettings()
+		}
+		policy.ConnectionPool = app.Policy.Streaming.ApplyConnectionPool(policy.ConnectionPool)
+	}
+
+	if policy.LoadBalancer == nil && policy.ConnectionPool == nil && policy.OutlierDetection == nil {
+		return nil
+	}
+	return policy
+}
+
 func (r *DestinationRule) generateLoadBalancerSettings() *istioapiv1.LoadBalancerSettings {
-	return &istioapiv1.LoadBalancerSettings{
-		LbPolicy: &istioapiv1.LoadBalancerSettings_Simple{Simple: istioapiv1.LoadBalancerSettings_ROUND_ROBIN},
+	if r.plus.Spec.Policy == nil || r.plus.Spec.Policy.LoadBalancer == nil {
+		return &istioapiv1.LoadBalancerSettings{
+			LbPolicy:          &istioapiv1.LoadBalancerSettings_Simple{Simple: istioapiv1.LoadBalancerSettings_ROUND_ROBIN},
+			LocalityLbSetting: r.plus.GetLocality().GetLocalityLbSetting(),
+		}
 	}
+	settings := r.plus.Spec.Policy.LoadBalancer.GetLoadBalancerSettings()
+	settings.LocalityLbSetting = r.plus.GetLocality().GetLocalityLbSetting()
+	return settings
 }
 
 func (r *DestinationRule) generateConnectionPoolSettings() *istioapiv1.ConnectionPoolSettings {
 	if r.plus.Spec.Policy == nil {
 		return nil
 	}
+	return r.plus.Spec.Policy.Streaming.ApplyConnectionPool(r.generateBaseConnectionPoolSettings())
+}
 
-	return &istioapiv1.ConnectionPoolSettings{
-		//Tcp:  &istioapiv1.ConnectionPoolSettings_TCPSettings{},
-		Http: &istioapiv1.ConnectionPoolSettings_HTTPSettings{
-			Http1MaxPendingRequests:  r.plus.Spec.Policy.MaxRequest,
-			Http2MaxRequests:         r.plus.Spec.Policy.MaxRequest,
-			MaxRequestsPerConnection: 0,
-		},
+// generateBaseConnectionPoolSettings 不包含长连接模式的连接池配置，版本的长连接模式在此基础上补充
+func (r *DestinationRule) generateBaseConnectionPoolSettings() *istioapiv1.ConnectionPoolSettings {
+	if r.plus.Spec.Policy == nil {
+		return (*v1.PlusPolicyConnectionPool)(nil).GetConnectionPoolSettings(0)
 	}
+	return r.plus.Spec.Policy.ConnectionPool.GetConnectionPoolSettings(r.plus.Spec.Policy.MaxRequest)
 }
 
 func (r *DestinationRule) generateOutlierDetection() *istioapiv1.OutlierDetection {
 	if r.plus.Spec.Policy == nil || r.plus.Spec.Policy.OutlierDetection == nil {
 		return nil
 	}
+	return r.buildOutlierDetection(r.plus.Spec.Policy.OutlierDetection)
+}
 
+func (r *DestinationRule) buildOutlierDetection(outlierDetection *v1.PlusPolicyOutlierDetection) *istioapiv1.OutlierDetection {
 	return &istioapiv1.OutlierDetection{
-		Consecutive_5XxErrors: r.plus.Spec.Policy.OutlierDetection.GetConsecutiveErrors(),
-		Interval:              r.plus.Spec.Policy.OutlierDetection.GetInterval(),
-		BaseEjectionTime:      r.plus.Spec.Policy.OutlierDetection.GetEjectionTime(),
-		MaxEjectionPercent:    r.plus.Spec.Policy.OutlierDetection.MaxEjectionPercent,
-		MinHealthPercent:      r.plus.Spec.Policy.OutlierDetection.MinHealthPercent,
+		Consecutive_5XxErrors:          outlierDetection.GetConsecutiveErrors(),
+		ConsecutiveGatewayErrors:       outlierDetection.GetConsecutiveGatewayErrors(),
+		ConsecutiveLocalOriginFailures: outlierDetection.GetConsecutiveLocalOriginFailures(),
+		SplitExternalLocalOriginErrors: outlierDetection.SplitExternalLocalOriginErrors,
+		Interval:                       outlierDetection.GetInterval(),
+		BaseEjectionTime:               outlierDetection.GetEjectionTime(),
+		MaxEjectionPercent:             outlierDetection.MaxEjectionPercent,
+		MinHealthPercent:               outlierDetection.MinHealthPercent,
 	}
 }
 
-func (r *DestinationRule) exist() (bool, *istioclientapiv1.DestinationRule, error) {
+func (r *DestinationRule) exist(name string) (bool, *istioclientapiv1.DestinationRule, error) {
 
 	found := &istioclientapiv1.DestinationRule{}
-	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName(), Namespace: r.plus.GetNamespace()}, found)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil, nil
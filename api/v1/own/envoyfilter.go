@@ -0,0 +1,377 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	"google.golang.org/protobuf/types/known/structpb"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+	"strconv"
+	"time"
+)
+
+const (
+	localRateLimitFilter  = "envoy.filters.http.local_ratelimit"
+	globalRateLimitFilter = "envoy.filters.http.ratelimit"
+	// 本地限流描述符的值加上前缀，避免和全局限流的规则同名
+	localRateLimitPrefix = "local-"
+)
+
+type EnvoyFilter struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewEnvoyFilter(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *EnvoyFilter {
+	d := &EnvoyFilter{
+		plus:   plus,
+		logger: logger.WithValues("Own", "EnvoyFilter"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *EnvoyFilter) Apply() error {
+	obj, err := r.generateRateLimit()
+	if err != nil {
+		return err
+	}
+	return r.apply(r.plus.GetName()+"-ratelimit", obj)
+}
+
+// apply 创建或更新，obj 为 nil 时删除已经存在的资源
+func (r *EnvoyFilter) apply(name string, obj *istioclientapiv1.EnvoyFilter) error {
+	exist, found, err := r.exist(name)
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!", "Name", name)
+			if err := r.client.Delete(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!", "Name", name)
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 EnvoyFilter 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("envoy filter %s already exists and is not controlled by plus %s", name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Spec.WorkloadSelector, found.Spec.WorkloadSelector) ||
+		!reflect.DeepEqual(obj.Spec.ConfigPatches, found.Spec.ConfigPatches) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!", "Name", name)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *EnvoyFilter) UpdateStatus() error {
+	return nil
+}
+
+func (r *EnvoyFilter) Type() string {
+	return "EnvoyFilter"
+}
+
+// generateRateLimit 在 sidecar 入口插入限流 filter，并在路由上配置描述符。
+// 本地和全局限流共用路由上的 rate_limits，各自只处理自己认识的描述符
+func (r *EnvoyFilter) generateRateLimit() (*istioclientapiv1.EnvoyFilter, error) {
+	if r.plus.Spec.Policy == nil || r.plus.Spec.Policy.RateLimit == nil {
+		return nil, nil
+	}
+	rateLimit := r.plus.Spec.Policy.RateLimit
+
+	patches := make([]*istioapiv1.EnvoyFilter_EnvoyConfigObjectPatch, 0)
+	rateLimits := make([]interface{}, 0)
+	routeValue := make(map[string]interface{})
+
+	if len(rateLimit.Local) > 0 {
+		filter, err := structpb.NewStruct(r.generateLocalRateLimitFilter())
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, r.generateHttpFilterPatch(filter))
+
+		for _, rule := range rateLimit.Local {
+			rateLimits = append(rateLimits, r.generateRateLimitActions(localRateLimitPrefix+rule.Name, rule))
+		}
+		routeValue["typed_per_filter_config"] = map[string]interface{}{
+			localRateLimitFilter: r.generateLocalRateLimitRoute(rateLimit.Local),
+		}
+	}
+
+	if global := rateLimit.Global; global != nil && len(global.Rules) > 0 {
+		filter, err := structpb.NewStruct(r.generateGlobalRateLimitFilter(global))
+		if err != nil {
+			return nil, err
+		}
+		patches = append(patches, r.generateHttpFilterPatch(filter))
+
+		for _, rule := range global.Rules {
+			rateLimits = append(rateLimits, r.generateRateLimitActions(rule.Name, rule))
+		}
+	}
+
+	if len(patches) == 0 {
+		return nil, nil
+	}
+
+	routeValue["route"] = map[string]interface{}{
+		"rate_limits": rateLimits,
+	}
+	route, err := structpb.NewStruct(routeValue)
+	if err != nil {
+		return nil, err
+	}
+	patches = append(patches, &istioapiv1.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: istioapiv1.EnvoyFilter_HTTP_ROUTE,
+		Match: &istioapiv1.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: istioapiv1.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &istioapiv1.EnvoyFilter_EnvoyConfigObjectMatch_RouteConfiguration{
+				RouteConfiguration: &istioapiv1.EnvoyFilter_RouteConfigurationMatch{
+					Vhost: &istioapiv1.EnvoyFilter_RouteConfigurationMatch_VirtualHostMatch{
+						Route: &istioapiv1.EnvoyFilter_RouteConfigurationMatch_RouteMatch{
+							Action: istioapiv1.EnvoyFilter_RouteConfigurationMatch_RouteMatch_ANY,
+						},
+					},
+				},
+			},
+		},
+		Patch: &istioapiv1.EnvoyFilter_Patch{
+			Operation: istioapiv1.EnvoyFilter_Patch_MERGE,
+			Value:     route,
+		},
+	})
+
+	ef := &istioclientapiv1.EnvoyFilter{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName() + "-ratelimit",
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istioapiv1.EnvoyFilter{
+			WorkloadSelector: &istioapiv1.WorkloadSelector{
+				Labels: r.plus.GenerateLabels(),
+			},
+			ConfigPatches: patches,
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, ef, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return ef, nil
+}
+
+// generateHttpFilterPatch 在 router 之前插入 http filter
+func (r *EnvoyFilter) generateHttpFilterPatch(filter *structpb.Struct) *istioapiv1.EnvoyFilter_EnvoyConfigObjectPatch {
+	return &istioapiv1.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: istioapiv1.EnvoyFilter_HTTP_FILTER,
+		Match: &istioapiv1.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: istioapiv1.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &istioapiv1.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: &istioapiv1.EnvoyFilter_ListenerMatch{
+					FilterChain: &istioapiv1.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						Filter: &istioapiv1.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: "envoy.filters.network.http_connection_manager",
+							SubFilter: &istioapiv1.EnvoyFilter_ListenerMatch_SubFilterMatch{
+								Name: "envoy.filters.http.router",
+							},
+						},
+					},
+				},
+			},
+		},
+		Patch: &istioapiv1.EnvoyFilter_Patch{
+			Operation: istioapiv1.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     filter,
+		},
+	}
+}
+
+// generateLocalRateLimitFilter 监听器上的 filter 不配置令牌桶，只有路由上配置的规则生效
+func (r *EnvoyFilter) generateLocalRateLimitFilter() map[string]interface{} {
+	return map[string]interface{}{
+		"name": localRateLimitFilter,
+		"typed_config": map[string]interface{}{
+			"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+			"type_url": "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+			"value": map[string]interface{}{
+				"stat_prefix": "http_local_rate_limiter",
+			},
+		},
+	}
+}
+
+// generateLocalRateLimitRoute 路由级别的本地限流配置，没有命中规则的请求使用足够大的默认令牌桶，相当于不限流
+func (r *EnvoyFilter) generateLocalRateLimitRoute(rules []*v1.PlusRateLimitRule) map[string]interface{} {
+	descriptors := make([]interface{}, 0, len(rules))
+	for _, rule := range rules {
+		descriptors = append(descriptors, map[string]interface{}{
+			"entries": []interface{}{
+				map[string]interface{}{
+					"key":   v1.RateLimitDescriptorKey,
+					"value": localRateLimitPrefix + rule.Name,
+				},
+			},
+			"token_bucket": r.generateTokenBucket(rule.GetBurst(), rule.Requests, formatEnvoyDuration(rule.GetInterval())),
+		})
+	}
+
+	enabled := map[string]interface{}{
+		"runtime_key": "local_rate_limit_enabled",
+		"default_value": map[string]interface{}{
+			"numerator":   100,
+			"denominator": "HUNDRED",
+		},
+	}
+	enforced := map[string]interface{}{
+		"runtime_key": "local_rate_limit_enforced",
+		"default_value": map[string]interface{}{
+			"numerator":   100,
+			"denominator": "HUNDRED",
+		},
+	}
+
+	return map[string]interface{}{
+		"@type":    "type.googleapis.com/udpa.type.v1.TypedStruct",
+		"type_url": "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+		"value": map[string]interface{}{
+			"stat_prefix":     "http_local_rate_limiter",
+			"token_bucket":    r.generateTokenBucket(1000000, 1000000, "1s"),
+			"filter_enabled":  enabled,
+			"filter_enforced": enforced,
+			"descriptors":     descriptors,
+			"response_headers_to_add": []interface{}{
+				map[string]interface{}{
+					"append": false,
+					"header": map[string]interface{}{
+						"key":   "x-local-rate-limit",
+						"value": "true",
+					},
+				},
+			},
+		},
+	}
+}
+
+func (r *EnvoyFilter) generateTokenBucket(maxTokens, tokensPerFill uint32, fillInterval string) map[string]interface{} {
+	return map[string]interface{}{
+		"max_tokens":      maxTokens,
+		"tokens_per_fill": tokensPerFill,
+		"fill_interval":   fillInterval,
+	}
+}
+
+// generateGlobalRateLimitFilter 调用外部限流服务的 filter
+func (r *EnvoyFilter) generateGlobalRateLimitFilter(global *v1.PlusGlobalRateLimit) map[string]interface{} {
+	grpcService := map[string]interface{}{
+		"envoy_grpc": map[string]interface{}{
+			"cluster_name": global.GetClusterName(),
+		},
+	}
+	config := map[string]interface{}{
+		"@type":             "type.googleapis.com/envoy.extensions.filters.http.ratelimit.v3.RateLimit",
+		"domain":            global.GetDomain(r.plus),
+		"failure_mode_deny": global.FailureModeDeny,
+		"rate_limit_service": map[string]interface{}{
+			"grpc_service":          grpcService,
+			"transport_api_version": "V3",
+		},
+	}
+	if timeout, err := time.ParseDuration(global.Timeout); err == nil {
+		config["timeout"] = formatEnvoyDuration(timeout)
+		grpcService["timeout"] = formatEnvoyDuration(timeout)
+	}
+	return map[string]interface{}{
+		"name":         globalRateLimitFilter,
+		"typed_config": config,
+	}
+}
+
+// generateRateLimitActions 请求满足规则的 path、method、headers 时生成描述符 header_match=value
+func (r *EnvoyFilter) generateRateLimitActions(value string, rule *v1.PlusRateLimitRule) map[string]interface{} {
+	headers := []interface{}{
+		map[string]interface{}{
+			"name":         ":path",
+			"string_match": map[string]interface{}{"prefix": rule.GetPath()},
+		},
+	}
+	if rule.Method != "" {
+		headers = append(headers, map[string]interface{}{
+			"name":         ":method",
+			"string_match": map[string]interface{}{"exact": rule.Method},
+		})
+	}
+
+	keys := make([]string, 0, len(rule.Headers))
+	for k := range rule.Headers {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		headers = append(headers, map[string]interface{}{
+			"name":         k,
+			"string_match": map[string]interface{}{"exact": rule.Headers[k]},
+		})
+	}
+
+	return map[string]interface{}{
+		"actions": []interface{}{
+			map[string]interface{}{
+				"header_value_match": map[string]interface{}{
+					"descriptor_value": value,
+					"headers":          headers,
+				},
+			},
+		},
+	}
+}
+
+// formatEnvoyDuration envoy 配置中的时间只支持以 s 为单位的格式，如 0.5s
+func formatEnvoyDuration(d time.Duration) string {
+	return strconv.FormatFloat(d.Seconds(), 'f', -1, 64) + "s"
+}
+
+func (r *EnvoyFilter) exist(name string) (bool, *istioclientapiv1.EnvoyFilter, error) {
+	found := &istioclientapiv1.EnvoyFilter{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error", "Name", name)
+		return true, found, err
+	}
+	return true, found, nil
+}
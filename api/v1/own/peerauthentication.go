@@ -0,0 +1,131 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	istiosecurityv1beta1 "istio.io/api/security/v1beta1"
+	istiotypev1beta1 "istio.io/api/type/v1beta1"
+	istiosecurityclientv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type PeerAuthentication struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewPeerAuthentication(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *PeerAuthentication {
+	d := &PeerAuthentication{
+		plus:   plus,
+		logger: logger.WithValues("Own", "PeerAuthentication"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *PeerAuthentication) Apply() error {
+	obj, err := r.generate()
+	if err != nil {
+		return err
+	}
+
+	exist, found, err := r.exist()
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!")
+			if err := r.client.Delete(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!")
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 PeerAuthentication 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("peer authentication %s already exists and is not controlled by plus %s", obj.Name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Selector, found.Spec.Selector) ||
+		!reflect.DeepEqual(obj.Spec.Mtls, found.Spec.Mtls) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!")
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PeerAuthentication) UpdateStatus() error {
+	return nil
+}
+
+func (r *PeerAuthentication) Type() string {
+	return "PeerAuthentication"
+}
+
+// generate 没有配置 mtls 时使用网格(命名空间)默认的配置
+func (r *PeerAuthentication) generate() (*istiosecurityclientv1beta1.PeerAuthentication, error) {
+	if r.plus.Spec.Access == nil || r.plus.Spec.Access.Mtls == "" {
+		return nil, nil
+	}
+
+	pa := &istiosecurityclientv1beta1.PeerAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName(),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istiosecurityv1beta1.PeerAuthentication{
+			Selector: &istiotypev1beta1.WorkloadSelector{
+				MatchLabels: r.plus.GenerateLabels(),
+			},
+			Mtls: &istiosecurityv1beta1.PeerAuthentication_MutualTLS{
+				Mode: istiosecurityv1beta1.PeerAuthentication_MutualTLS_Mode(istiosecurityv1beta1.PeerAuthentication_MutualTLS_Mode_value[r.plus.Spec.Access.Mtls]),
+			},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, pa, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return pa, nil
+}
+
+func (r *PeerAuthentication) exist() (bool, *istiosecurityclientv1beta1.PeerAuthentication, error) {
+	found := &istiosecurityclientv1beta1.PeerAuthentication{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName(), Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
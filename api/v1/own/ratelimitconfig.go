@@ -0,0 +1,141 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// RateLimitConfigLabel 限流服务通过该 label 发现需要加载的配置
+	RateLimitConfigLabel = "clusterplus.io/ratelimit-config"
+	// RateLimitConfigKey ConfigMap 中描述符配置的 key，文件格式和 envoyproxy/ratelimit 一致
+	RateLimitConfigKey = "config.yaml"
+)
+
+// RateLimitConfig 全局限流的描述符配置，保存在 <name>-ratelimit ConfigMap 中
+type RateLimitConfig struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewRateLimitConfig(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *RateLimitConfig {
+	d := &RateLimitConfig{
+		plus:   plus,
+		logger: logger.WithValues("Own", "RateLimitConfig"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *RateLimitConfig) Apply() error {
+	obj, err := r.generate()
+	if err != nil {
+		return err
+	}
+
+	exist, found, err := r.exist()
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!")
+			if err := r.client.Delete(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!")
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 ConfigMap 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("config map %s already exists and is not controlled by plus %s", obj.Name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Data, found.Data) ||
+		!reflect.DeepEqual(obj.Labels, found.Labels) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!")
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RateLimitConfig) UpdateStatus() error {
+	return nil
+}
+
+func (r *RateLimitConfig) Type() string {
+	return "RateLimitConfig"
+}
+
+func (r *RateLimitConfig) generate() (*corev1.ConfigMap, error) {
+	config := r.plus.GenerateRateLimitConfig()
+	if config == nil {
+		return nil, nil
+	}
+
+	data, err := yaml.Marshal(config)
+	if err != nil {
+		return nil, err
+	}
+
+	labels := r.plus.GenerateLabels()
+	labels[RateLimitConfigLabel] = "true"
+	labels[v1.ConfigMapLabel] = v1.ConfigMapRateLimit
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName() + "-ratelimit",
+			Namespace: r.plus.GetNamespace(),
+			Labels:    labels,
+		},
+		Data: map[string]string{
+			RateLimitConfigKey: string(data),
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, cm, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return cm, nil
+}
+
+func (r *RateLimitConfig) exist() (bool, *corev1.ConfigMap, error) {
+	found := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName() + "-ratelimit", Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
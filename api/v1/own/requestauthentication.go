@@ -0,0 +1,176 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	istiosecurityv1beta1 "istio.io/api/security/v1beta1"
+	istiotypev1beta1 "istio.io/api/type/v1beta1"
+	istiosecurityclientv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type RequestAuthentication struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewRequestAuthentication(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *RequestAuthentication {
+	d := &RequestAuthentication{
+		plus:   plus,
+		logger: logger.WithValues("Own", "RequestAuthentication"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *RequestAuthentication) Apply() error {
+	obj, err := r.generate()
+	if err != nil {
+		return err
+	}
+
+	exist, found, err := r.exist()
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!")
+			if err := r.client.Delete(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!")
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 RequestAuthentication 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("request authentication %s already exists and is not controlled by plus %s", obj.Name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Selector, found.Spec.Selector) ||
+		!reflect.DeepEqual(obj.Spec.JwtRules, found.Spec.JwtRules) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!")
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RequestAuthentication) UpdateStatus() error {
+	return nil
+}
+
+func (r *RequestAuthentication) Type() string {
+	return "RequestAuthentication"
+}
+
+func (r *RequestAuthentication) generate() (*istiosecurityclientv1beta1.RequestAuthentication, error) {
+	if r.plus.Spec.Gateway == nil || r.plus.Spec.Gateway.Access == nil || r.plus.Spec.Gateway.Access.Jwt == nil {
+		return nil, nil
+	}
+	jwt := r.plus.Spec.Gateway.Access.Jwt
+
+	jwks, err := r.generateJwks(jwt)
+	if err != nil {
+		return nil, err
+	}
+
+	ra := &istiosecurityclientv1beta1.RequestAuthentication{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName(),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istiosecurityv1beta1.RequestAuthentication{
+			Selector: &istiotypev1beta1.WorkloadSelector{
+				MatchLabels: r.plus.GenerateLabels(),
+			},
+			JwtRules: []*istiosecurityv1beta1.JWTRule{{
+				Issuer:               jwt.Issuer,
+				Audiences:            jwt.Audiences,
+				JwksUri:              jwt.JwksUri,
+				Jwks:                 jwks,
+				ForwardOriginalToken: jwt.ForwardOriginalToken,
+			}},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, ra, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return ra, nil
+}
+
+// generateJwks 读取内联或者 ConfigMap 中的 jwks，ConfigMap 变化后在下一次调和时生效
+func (r *RequestAuthentication) generateJwks(jwt *v1.PlusGatewayJwt) (string, error) {
+	if jwt.JwksFrom == nil {
+		return jwt.Jwks, nil
+	}
+
+	cm := &corev1.ConfigMap{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: jwt.JwksFrom.Name, Namespace: r.plus.GetNamespace()}, cm)
+	if err != nil {
+		if errors.IsNotFound(err) && jwt.JwksFrom.Optional != nil && *jwt.JwksFrom.Optional {
+			return "", nil
+		}
+		return "", err
+	}
+
+	// controller 只 watch 带有 ConfigMapLabel 的 ConfigMap，添加后 jwks 变化时重新生成
+	if _, ok := cm.Labels[v1.ConfigMapLabel]; !ok {
+		patch := client.MergeFrom(cm.DeepCopy())
+		if cm.Labels == nil {
+			cm.Labels = make(map[string]string)
+		}
+		cm.Labels[v1.ConfigMapLabel] = v1.ConfigMapJwks
+		r.logger.Info("Labeling jwks configmap!", "Name", cm.Name)
+		if err := r.client.Patch(context.TODO(), cm, patch); err != nil {
+			return "", err
+		}
+	}
+
+	jwks, ok := cm.Data[jwt.JwksFrom.Key]
+	if !ok {
+		return "", fmt.Errorf("key %s not found in configmap %s", jwt.JwksFrom.Key, jwt.JwksFrom.Name)
+	}
+	return jwks, nil
+}
+
+func (r *RequestAuthentication) exist() (bool, *istiosecurityclientv1beta1.RequestAuthentication, error) {
+	found := &istiosecurityclientv1beta1.RequestAuthentication{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName(), Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
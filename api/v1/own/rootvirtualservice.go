@@ -0,0 +1,235 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sort"
+	"strings"
+)
+
+// RootVirtualService 多个 Plus 共用域名时，每个域名生成一个根 VirtualService，按照最长前缀优先委托给各 Plus 的 VirtualService。
+// 根 VirtualService 属于多个 Plus，不设置 ControllerReference，没有委托路由时删除
+type RootVirtualService struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewRootVirtualService(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *RootVirtualService {
+	d := &RootVirtualService{
+		plus:   plus,
+		logger: logger.WithValues("Own", "RootVirtualService"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply 重新生成该 Plus 委托的域名，以及之前委托过的域名(域名变化或者 Plus 删除时需要移除路由)
+func (r *RootVirtualService) Apply() error {
+	keys := make(map[types.NamespacedName]rootKey)
+	for gateway, hosts := range r.plus.GetDelegateHosts() {
+		for _, host := range hosts {
+			namespace, name := v1.GetRootVirtualService(gateway, host)
+			keys[types.NamespacedName{Namespace: namespace, Name: name}] = rootKey{gateway: gateway, host: host}
+		}
+	}
+
+	referenced, err := r.listReferenced()
+	if err != nil {
+		return err
+	}
+	for _, found := range referenced {
+		if len(found.Spec.Gateways) == 0 || len(found.Spec.Hosts) == 0 {
+			continue
+		}
+		key := rootKey{gateway: found.Spec.Gateways[0], host: found.Spec.Hosts[0]}
+		namespace, name := v1.GetRootVirtualService(key.gateway, key.host)
+		keys[types.NamespacedName{Namespace: namespace, Name: name}] = key
+		// 之前版本生成的名称没有域名的摘要，由新名称的根 VirtualService 代替
+		if found.Namespace != namespace || found.Name != name {
+			r.logger.Info("Renamed, delete it!", "Name", found.Name)
+			if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+	}
+
+	names := make([]types.NamespacedName, 0, len(keys))
+	for name := range keys {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool { return names[i].String() < names[j].String() })
+
+	for _, name := range names {
+		if err := r.apply(name, keys[name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type rootKey struct {
+	gateway string
+	host    string
+}
+
+func (r *RootVirtualService) apply(name types.NamespacedName, key rootKey) error {
+	obj, err := r.generate(name, key)
+	if err != nil {
+		return err
+	}
+
+	exist, found, err := r.exist(name)
+	if err != nil {
+		return err
+	}
+
+	// 同名的 VirtualService 不是根 VirtualService 或者属于其他域名，不能修改
+	if exist && (found.Labels[v1.RootVirtualServiceLabel] != v1.GetRootHostHash(key.host) ||
+		len(found.Spec.Hosts) != 1 || found.Spec.Hosts[0] != key.host) {
+		if obj == nil {
+			return nil
+		}
+		return fmt.Errorf("virtual service %s already exists and is not the root virtual service of %s", name, key.host)
+	}
+
+	if obj == nil {
+		if exist {
+			r.logger.Info("Not required, delete it!", "Name", name)
+			if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!", "Name", name)
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Hosts, found.Spec.Hosts) ||
+		!reflect.DeepEqual(obj.Spec.Gateways, found.Spec.Gateways) ||
+		!reflect.DeepEqual(obj.Spec.Http, found.Spec.Http) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!", "Name", name)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *RootVirtualService) UpdateStatus() error {
+	return nil
+}
+
+func (r *RootVirtualService) Type() string {
+	return "RootVirtualService"
+}
+
+// generate 汇总使用该域名和网关的所有 Plus 的委托路由，没有路由时返回 nil
+func (r *RootVirtualService) generate(name types.NamespacedName, key rootKey) (*istioclientapiv1.VirtualService, error) {
+	list := &v1.PlusList{}
+	if err := r.client.List(context.TODO(), list, client.MatchingFields{v1.GatewayHostsField: key.host}); err != nil {
+		return nil, err
+	}
+
+	routes := make([]v1.PlusDelegateRoute, 0)
+	for i := range list.Items {
+		plus := &list.Items[i]
+		// 缓存中的 Plus 可能比正在调和的旧
+		if plus.GetNamespace() == r.plus.GetNamespace() && plus.GetName() == r.plus.GetName() {
+			plus = r.plus
+		}
+		routes = append(routes, plus.GetDelegateRoutes(key.gateway, key.host)...)
+	}
+	if len(routes) == 0 {
+		return nil, nil
+	}
+	v1.SortDelegateRoutes(routes)
+
+	httpRoutes := make([]*istioapiv1.HTTPRoute, 0, len(routes))
+	for _, route := range routes {
+		httpRoute := &istioapiv1.HTTPRoute{
+			Name: fmt.Sprintf("%s.%s%s", route.Name, route.Namespace, route.Prefix),
+			Delegate: &istioapiv1.Delegate{
+				Name:      route.Name,
+				Namespace: route.Namespace,
+			},
+		}
+		if route.Prefix != "" {
+			httpRoute.Match = []*istioapiv1.HTTPMatchRequest{{
+				Uri: &istioapiv1.StringMatch{
+					MatchType: &istioapiv1.StringMatch_Prefix{Prefix: route.Prefix},
+				},
+			}}
+		}
+		httpRoutes = append(httpRoutes, httpRoute)
+	}
+
+	return &istioclientapiv1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      name.Name,
+			Namespace: name.Namespace,
+			Labels:    map[string]string{v1.RootVirtualServiceLabel: v1.GetRootHostHash(key.host)},
+		},
+		Spec: istioapiv1.VirtualService{
+			Hosts:    []string{key.host},
+			Gateways: []string{key.gateway},
+			Http:     httpRoutes,
+		},
+	}, nil
+}
+
+// listReferenced 查找委托到该 Plus 的根 VirtualService
+func (r *RootVirtualService) listReferenced() ([]*istioclientapiv1.VirtualService, error) {
+	list := &istioclientapiv1.VirtualServiceList{}
+	if err := r.client.List(context.TODO(), list, client.HasLabels{v1.RootVirtualServiceLabel}); err != nil {
+		return nil, err
+	}
+	referenced := make([]*istioclientapiv1.VirtualService, 0)
+	for _, found := range list.Items {
+		for _, route := range found.Spec.Http {
+			if route.Delegate != nil && route.Delegate.Namespace == r.plus.GetNamespace() && r.isOwnVirtualService(route.Delegate.Name) {
+				referenced = append(referenced, found)
+				break
+			}
+		}
+	}
+	return referenced, nil
+}
+
+// isOwnVirtualService 是否为该 Plus 网关的 VirtualService <name>-gateway 或者 <name>-gateway-<listener>。
+// listener 删除后也需要从根 VirtualService 中移除，误匹配其他 Plus 时只是多重新生成一次
+func (r *RootVirtualService) isOwnVirtualService(name string) bool {
+	prefix := r.plus.GetName() + "-gateway"
+	return name == prefix || strings.HasPrefix(name, prefix+"-")
+}
+
+func (r *RootVirtualService) exist(name types.NamespacedName) (bool, *istioclientapiv1.VirtualService, error) {
+	found := &istioclientapiv1.VirtualService{}
+	err := r.client.Get(context.TODO(), name, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
@@ -0,0 +1,152 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"github.com/go-logr/logr"
+	"github.com/stretchr/testify/require"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func newDelegatePlus(namespace, name string, hosts ...string) *v1.Plus {
+	return &v1.Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: namespace},
+		Spec:       v1.PlusSpec{Gateway: &v1.PlusGateway{Hosts: hosts, Delegate: true}},
+	}
+}
+
+func newRootVirtualService(t *testing.T, plus *v1.Plus, c client.Client) *RootVirtualService {
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1.AddToScheme(scheme))
+	require.Nil(t, istioclientapiv1.AddToScheme(scheme))
+	return NewRootVirtualService(plus, scheme, c, logr.Discard())
+}
+
+// 测试用的 fake client 不支持字段索引，List 会返回所有的 Plus，由 GetDelegateRoutes 按照域名过滤
+func newFakeClient(t *testing.T, objs ...client.Object) client.Client {
+	scheme := runtime.NewScheme()
+	require.Nil(t, v1.AddToScheme(scheme))
+	require.Nil(t, istioclientapiv1.AddToScheme(scheme))
+	return fake.NewClientBuilder().WithScheme(scheme).WithObjects(objs...).Build()
+}
+
+func rootName(host string) types.NamespacedName {
+	namespace, name := v1.GetRootVirtualService(v1.DefaultGateway, host)
+	return types.NamespacedName{Namespace: namespace, Name: name}
+}
+
+func TestRootVirtualServiceGenerate(t *testing.T) {
+	svcC := newDelegatePlus("default", "svc-c", "api.a.com")
+	svcCSub := newDelegatePlus("other", "svc-c-sub", "api.a.com")
+	svcD := newDelegatePlus("default", "svc-d", "api.b.com")
+	c := newFakeClient(t, svcC, svcCSub, svcD)
+
+	name := rootName("api.a.com")
+	obj, err := newRootVirtualService(t, svcC, c).generate(name, rootKey{gateway: v1.DefaultGateway, host: "api.a.com"})
+	require.Nil(t, err)
+	require.Equal(t, []string{"api.a.com"}, obj.Spec.Hosts)
+	require.Equal(t, []string{v1.DefaultGateway}, obj.Spec.Gateways)
+	require.Equal(t, v1.GetRootHostHash("api.a.com"), obj.Labels[v1.RootVirtualServiceLabel])
+
+	// 最长前缀优先
+	require.Len(t, obj.Spec.Http, 2)
+	require.Equal(t, "/svc-c-sub", obj.Spec.Http[0].Match[0].Uri.GetPrefix())
+	require.Equal(t, "svc-c-sub-gateway", obj.Spec.Http[0].Delegate.Name)
+	require.Equal(t, "other", obj.Spec.Http[0].Delegate.Namespace)
+	require.Equal(t, "/svc-c", obj.Spec.Http[1].Match[0].Uri.GetPrefix())
+	require.Equal(t, "svc-c-gateway", obj.Spec.Http[1].Delegate.Name)
+
+	// 使用正在调和的 Plus 而不是缓存中的
+	svcC.Spec.Gateway.Delegate = false
+	obj, err = newRootVirtualService(t, svcC, c).generate(name, rootKey{gateway: v1.DefaultGateway, host: "api.a.com"})
+	require.Nil(t, err)
+	require.Len(t, obj.Spec.Http, 1)
+	require.Equal(t, "svc-c-sub-gateway", obj.Spec.Http[0].Delegate.Name)
+
+	// 没有委托路由时不生成
+	obj, err = newRootVirtualService(t, svcC, c).generate(rootName("api.c.com"), rootKey{gateway: v1.DefaultGateway, host: "api.c.com"})
+	require.Nil(t, err)
+	require.Nil(t, obj)
+}
+
+func TestRootVirtualServiceApply(t *testing.T) {
+	svcC := newDelegatePlus("default", "svc-c", "api.a.com")
+	c := newFakeClient(t, svcC)
+	name := rootName("api.a.com")
+
+	require.Nil(t, newRootVirtualService(t, svcC, c).Apply())
+	found := &istioclientapiv1.VirtualService{}
+	require.Nil(t, c.Get(context.TODO(), name, found))
+	require.Len(t, found.Spec.Http, 1)
+	require.Equal(t, "svc-c-gateway", found.Spec.Http[0].Delegate.Name)
+
+	// 修改前缀后更新路由
+	prefix := "/c"
+	svcC.Spec.Gateway.PathPrefix = &prefix
+	require.Nil(t, newRootVirtualService(t, svcC, c).Apply())
+	require.Nil(t, c.Get(context.TODO(), name, found))
+	require.Equal(t, "/c", found.Spec.Http[0].Match[0].Uri.GetPrefix())
+
+	// Plus 删除时通过 listReferenced 找到之前委托的域名，没有路由后删除根 VirtualService
+	now := metav1.Now()
+	svcC.DeletionTimestamp = &now
+	require.Nil(t, newRootVirtualService(t, svcC, c).Apply())
+	require.True(t, errors.IsNotFound(c.Get(context.TODO(), name, found)))
+}
+
+func TestRootVirtualServiceListReferenced(t *testing.T) {
+	svcC := newDelegatePlus("default", "svc-c", "api.a.com")
+	svcCSub := newDelegatePlus("default", "svc-c-sub", "api.a.com")
+	svcCSub.Spec.Gateway.Listeners = []*v1.PlusGatewayListener{{Name: "internal", Hosts: []string{"api.b.com"}, Delegate: true}}
+	c := newFakeClient(t, svcC, svcCSub)
+
+	require.Nil(t, newRootVirtualService(t, svcCSub, c).Apply())
+
+	// svc-c-sub-gateway 和 svc-c-gateway-xxx 的名称前缀不同，api.b.com 只委托给了 svc-c-sub
+	referenced, err := newRootVirtualService(t, svcC, c).listReferenced()
+	require.Nil(t, err)
+	require.Len(t, referenced, 1)
+	require.Equal(t, rootName("api.a.com").Name, referenced[0].Name)
+
+	referenced, err = newRootVirtualService(t, svcCSub, c).listReferenced()
+	require.Nil(t, err)
+	require.Len(t, referenced, 2)
+
+	require.True(t, newRootVirtualService(t, svcCSub, c).isOwnVirtualService("svc-c-sub-gateway-internal"))
+	require.False(t, newRootVirtualService(t, svcC, c).isOwnVirtualService("svc-c-sub-gateway"))
+}
+
+func TestRootVirtualServiceOwnership(t *testing.T) {
+	svcW := newDelegatePlus("default", "svc-w", "*.a.com")
+	legacy := &istioclientapiv1.VirtualService{
+		ObjectMeta: metav1.ObjectMeta{Name: "gateway-wildcard-a-com", Namespace: "istio-system",
+			Labels: map[string]string{v1.RootVirtualServiceLabel: "a.com"}},
+	}
+	legacy.Spec.Hosts = []string{"*.a.com"}
+	legacy.Spec.Gateways = []string{v1.DefaultGateway}
+	legacy.Spec.Http = []*istioapiv1.HTTPRoute{{Delegate: &istioapiv1.Delegate{Name: "svc-w-gateway", Namespace: "default"}}}
+	c := newFakeClient(t, svcW, legacy)
+
+	// 通配符域名不能作为 label 的值，之前版本生成的根 VirtualService 被新名称代替
+	require.Nil(t, newRootVirtualService(t, svcW, c).Apply())
+	found := &istioclientapiv1.VirtualService{}
+	require.Nil(t, c.Get(context.TODO(), rootName("*.a.com"), found))
+	require.Equal(t, []string{"*.a.com"}, found.Spec.Hosts)
+	require.True(t, errors.IsNotFound(c.Get(context.TODO(), types.NamespacedName{Namespace: "istio-system", Name: legacy.Name}, found)))
+
+	// 同名但不是根 VirtualService 的不能修改
+	svcB := newDelegatePlus("default", "svc-b", "api.b.com")
+	manual := &istioclientapiv1.VirtualService{ObjectMeta: metav1.ObjectMeta{Name: rootName("api.b.com").Name, Namespace: "istio-system"}}
+	c = newFakeClient(t, svcB, manual)
+	require.NotNil(t, newRootVirtualService(t, svcB, c).Apply())
+	require.Nil(t, c.Get(context.TODO(), rootName("api.b.com"), found))
+	require.Empty(t, found.Spec.Http)
+}
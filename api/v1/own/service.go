@@ -35,38 +35,114 @@ func NewService(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, log
 
 // Apply this own resource, create or update
 func (r *Service) Apply() error {
-	for _, app := range r.plus.Spec.Apps {
-		obj, err := r.generate(app)
-		if err != nil {
+	if len(r.plus.Spec.Apps) == 0 {
+		return nil
+	}
+
+	names := map[string]bool{r.plus.GetName(): true}
+	obj, err := r.generate(r.plus.GetName(), r.plus.Spec.Apps, r.plus.GenerateLabels(), true)
+	if err != nil {
+		return err
+	}
+	if err := r.apply(obj); err != nil {
+		return err
+	}
+
+	if r.plus.Spec.Service.IsPerVersion() {
+		for _, app := range r.plus.Spec.Apps {
+			obj, err := r.generate(r.plus.GetVersionServiceName(app), []*v1.PlusApp{app}, r.plus.GenerateAppLabels(app), false)
+			if err != nil {
+				return err
+			}
+			if err := r.apply(obj); err != nil {
+				return err
+			}
+			names[obj.Name] = true
+		}
+	}
+	return r.deleteUnused(names)
+}
+
+func (r *Service) apply(obj *corev1.Service) error {
+	exist, found, err := r.exist(obj.Name)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!", "Name", obj.Name)
+		if err := r.client.Create(context.TODO(), obj); err != nil {
 			return err
 		}
+		return nil
+	}
+
+	// 同名的 Service 不是该 Plus 创建的，不能接管
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("service %s already exists and is not controlled by plus %s", obj.Name, r.plus.GetName())
+	}
 
-		exist, found, err := r.exist(app)
-		if err != nil {
+	// 只维护 spec.service 中配置的 annotations，保留云厂商等其他控制器添加的
+	obj.Annotations = mergeAnnotations(found.Annotations, obj.Annotations)
+
+	// clusterIP 不能修改，切换 headless 时需要重建
+	if (found.Spec.ClusterIP == corev1.ClusterIPNone) != (obj.Spec.ClusterIP == corev1.ClusterIPNone) {
+		r.logger.Info("Headless changed, recreate it!", "Name", obj.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
 			return err
 		}
+		return r.client.Create(context.TODO(), obj)
+	}
 
-		if !exist {
-			r.logger.Info("Not found, create it!")
-			if err := r.client.Create(context.TODO(), obj); err != nil {
-				return err
-			}
-			return nil
-		} else {
-			obj.ResourceVersion = found.ResourceVersion
-			if !reflect.DeepEqual(obj.Spec.Ports, found.Spec.Ports) ||
-				!reflect.DeepEqual(obj.Spec.Selector, found.Spec.Selector) ||
-				!reflect.DeepEqual(obj.Spec.SessionAffinity, found.Spec.SessionAffinity) ||
-				!reflect.DeepEqual(obj.Spec.Type, found.Spec.Type) {
-				r.logger.Info("Updating!")
-				if err := r.client.Update(context.TODO(), obj); err != nil {
-					return err
-				}
+	// 保留 k8s 分配的 nodePort
+	for i := range obj.Spec.Ports {
+		for _, port := range found.Spec.Ports {
+			if port.Port == obj.Spec.Ports[i].Port && obj.Spec.Type != corev1.ServiceTypeClusterIP {
+				obj.Spec.Ports[i].NodePort = port.NodePort
 			}
 		}
 	}
+
+	if !reflect.DeepEqual(obj.Spec.Ports, found.Spec.Ports) ||
+		!reflect.DeepEqual(obj.Spec.Selector, found.Spec.Selector) ||
+		!reflect.DeepEqual(obj.Spec.SessionAffinity, found.Spec.SessionAffinity) ||
+		!reflect.DeepEqual(obj.Spec.Type, found.Spec.Type) ||
+		!reflect.DeepEqual(obj.Spec.ExternalTrafficPolicy, found.Spec.ExternalTrafficPolicy) ||
+		!reflect.DeepEqual(obj.Spec.LoadBalancerSourceRanges, found.Spec.LoadBalancerSourceRanges) ||
+		!reflect.DeepEqual(obj.Annotations, found.Annotations) {
+		// 保留 clusterIP 等由 k8s 分配的字段
+		found.Annotations = obj.Annotations
+		found.Spec.Ports = obj.Spec.Ports
+		found.Spec.Selector = obj.Spec.Selector
+		found.Spec.SessionAffinity = obj.Spec.SessionAffinity
+		found.Spec.Type = obj.Spec.Type
+		found.Spec.ExternalTrafficPolicy = obj.Spec.ExternalTrafficPolicy
+		found.Spec.LoadBalancerSourceRanges = obj.Spec.LoadBalancerSourceRanges
+		r.logger.Info("Updating!", "Name", obj.Name)
+		if err := r.client.Update(context.TODO(), found); err != nil {
+			return err
+		}
+	}
 	return nil
+}
 
+// deleteUnused 删除关闭 perVersion 或者移除的版本对应的 Service，只删除由该 Plus 创建的
+func (r *Service) deleteUnused(names map[string]bool) error {
+	list := &corev1.ServiceList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(r.plus.GetNamespace()), client.MatchingLabels{"plus": r.plus.GetName()}); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		found := &list.Items[i]
+		if names[found.Name] || !metav1.IsControlledBy(found, r.plus) {
+			continue
+		}
+		r.logger.Info("Not required, delete it!", "Name", found.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
 }
 
 func (r *Service) UpdateStatus() error {
@@ -77,21 +153,40 @@ func (r *Service) Type() string {
 	return "Service"
 }
 
-func (r *Service) generate(app *v1.PlusApp) (*corev1.Service, error) {
+// generate 生成 Service，main 为 <name> Service，使用 spec.service 中的类型和 annotations，版本的 Service 只在集群内访问
+func (r *Service) generate(name string, apps []*v1.PlusApp, labels map[string]string, main bool) (*corev1.Service, error) {
+	config := r.plus.Spec.Service
 	service := &corev1.Service{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      r.plus.GetName(),
+			Name:      name,
 			Namespace: r.plus.GetNamespace(),
-			Labels:    r.plus.GenerateLabels(),
+			Labels:    labels,
 		},
 		Spec: corev1.ServiceSpec{
 			Type:            corev1.ServiceTypeClusterIP,
-			Selector:        r.plus.GenerateLabels(),
-			Ports:           r.buildPorts(app),
+			Selector:        labels,
+			Ports:           r.buildPorts(apps),
 			SessionAffinity: "None",
 		},
 	}
 
+	if config.IsHeadless() {
+		service.Spec.ClusterIP = corev1.ClusterIPNone
+	}
+
+	if main && config != nil {
+		service.Spec.Type = config.GetType()
+		service.Annotations = mergeAnnotations(nil, config.Annotations)
+		service.Spec.LoadBalancerSourceRanges = config.LoadBalancerSourceRanges
+		if service.Spec.Type == corev1.ServiceTypeNodePort || service.Spec.Type == corev1.ServiceTypeLoadBalancer {
+			// 和 k8s 的默认值一致，避免每次都更新
+			service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyTypeCluster
+			if config.ExternalTrafficPolicy != "" {
+				service.Spec.ExternalTrafficPolicy = corev1.ServiceExternalTrafficPolicyType(config.ExternalTrafficPolicy)
+			}
+		}
+	}
+
 	// 绑定关系，删除instance会删除底下所有资源
 	if err := controllerutil.SetControllerReference(r.plus, service, r.scheme); err != nil {
 		r.logger.Error(err, "Set controllerReference failed")
@@ -101,9 +196,9 @@ func (r *Service) generate(app *v1.PlusApp) (*corev1.Service, error) {
 }
 
 // Check if the Service already exists
-func (r *Service) exist(app *v1.PlusApp) (bool, *corev1.Service, error) {
+func (r *Service) exist(name string) (bool, *corev1.Service, error) {
 	found := &corev1.Service{}
-	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName(), Namespace: r.plus.GetNamespace()}, found)
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
 	if err != nil {
 		if errors.IsNotFound(err) {
 			return false, nil, nil
@@ -114,13 +209,21 @@ func (r *Service) exist(app *v1.PlusApp) (bool, *corev1.Service, error) {
 	return true, found, nil
 }
 
-func (r *Service) buildPorts(app *v1.PlusApp) []corev1.ServicePort {
+// buildPorts 所有版本的端口，多个版本使用相同端口时只保留一个
+func (r *Service) buildPorts(apps []*v1.PlusApp) []corev1.ServicePort {
 	ports := make([]corev1.ServicePort, 0, 1)
-	ports = append(ports, corev1.ServicePort{
-		Name:       fmt.Sprintf("%s-%d", app.Protocol, app.Port),
-		Protocol:   corev1.ProtocolTCP,
-		Port:       app.Port,
-		TargetPort: intstr.FromInt(int(app.Port)),
-	})
+	found := make(map[int32]bool, len(apps))
+	for _, app := range apps {
+		if found[app.Port] {
+			continue
+		}
+		found[app.Port] = true
+		ports = append(ports, corev1.ServicePort{
+			Name:       app.GetPortName(),
+			Protocol:   corev1.ProtocolTCP,
+			Port:       app.Port,
+			TargetPort: intstr.FromInt(int(app.Port)),
+		})
+	}
 	return ports
 }
@@ -0,0 +1,175 @@
+package own
+
+import (
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"context"
+	"fmt"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sort"
+	"strings"
+)
+
+// managedAnnotationsKey 记录由 Plus 设置的 annotations，更新时只修改这些 key，保留其他控制器或用户添加的 annotations
+const managedAnnotationsKey = "apps.clusterplus.io/managed-annotations"
+
+// mergeAnnotations 把 desired 合并到 current，删除上次由 Plus 设置但已经移除的 key，不修改 current
+func mergeAnnotations(current, desired map[string]string) map[string]string {
+	merged := make(map[string]string, len(current)+len(desired)+1)
+	for k, v := range current {
+		merged[k] = v
+	}
+	if managed := current[managedAnnotationsKey]; managed != "" {
+		for _, k := range strings.Split(managed, ",") {
+			delete(merged, k)
+		}
+	}
+	delete(merged, managedAnnotationsKey)
+
+	keys := make([]string, 0, len(desired))
+	for k, v := range desired {
+		if k == managedAnnotationsKey {
+			continue
+		}
+		merged[k] = v
+		keys = append(keys, k)
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		merged[managedAnnotationsKey] = strings.Join(keys, ",")
+	}
+	if len(merged) == 0 {
+		return nil
+	}
+	return merged
+}
+
+type ServiceAccount struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewServiceAccount(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *ServiceAccount {
+	d := &ServiceAccount{
+		plus:   plus,
+		logger: logger.WithValues("Own", "ServiceAccount"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *ServiceAccount) Apply() error {
+	serviceAccounts := r.plus.GenerateServiceAccounts()
+	names := make([]string, 0, len(serviceAccounts))
+	for name := range serviceAccounts {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		obj, err := r.generate(name, serviceAccounts[name])
+		if err != nil {
+			return err
+		}
+
+		exist, found, err := r.exist(name)
+		if err != nil {
+			return err
+		}
+
+		if !exist {
+			r.logger.Info("Not found, create it!", "Name", name)
+			if err := r.client.Create(context.TODO(), obj); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// 同名的 ServiceAccount 不是该 Plus 创建的，不能使用别人的身份
+		if !metav1.IsControlledBy(found, r.plus) {
+			return fmt.Errorf("service account %s already exists and is not controlled by plus %s", name, r.plus.GetName())
+		}
+
+		annotations := mergeAnnotations(found.Annotations, obj.Annotations)
+		if !reflect.DeepEqual(annotations, found.Annotations) ||
+			!reflect.DeepEqual(obj.Labels, found.Labels) {
+			// 保留 token 和 workload identity 等由 k8s 或其他控制器维护的字段
+			found.Annotations = annotations
+			found.Labels = obj.Labels
+			r.logger.Info("Updating!", "Name", name)
+			if err := r.client.Update(context.TODO(), found); err != nil {
+				return err
+			}
+		}
+	}
+
+	return r.deleteUnused(serviceAccounts)
+}
+
+// deleteUnused 删除不再使用的 ServiceAccount，只删除由该 Plus 创建的
+func (r *ServiceAccount) deleteUnused(serviceAccounts map[string]map[string]string) error {
+	list := &corev1.ServiceAccountList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(r.plus.GetNamespace()), client.MatchingLabels{"plus": r.plus.GetName()}); err != nil {
+		return err
+	}
+	for i := range list.Items {
+		found := &list.Items[i]
+		if _, ok := serviceAccounts[found.Name]; ok || !metav1.IsControlledBy(found, r.plus) {
+			continue
+		}
+		r.logger.Info("Not required, delete it!", "Name", found.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ServiceAccount) UpdateStatus() error {
+	return nil
+}
+
+func (r *ServiceAccount) Type() string {
+	return "ServiceAccount"
+}
+
+func (r *ServiceAccount) generate(name string, annotations map[string]string) (*corev1.ServiceAccount, error) {
+	sa := &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        name,
+			Namespace:   r.plus.GetNamespace(),
+			Labels:      r.plus.GenerateLabels(),
+			Annotations: mergeAnnotations(nil, annotations),
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, sa, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return sa, nil
+}
+
+func (r *ServiceAccount) exist(name string) (bool, *corev1.ServiceAccount, error) {
+	found := &corev1.ServiceAccount{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
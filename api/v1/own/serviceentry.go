@@ -0,0 +1,201 @@
+package own
+
+import (
+	"context"
+	"reflect"
+
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"github.com/go-logr/logr"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type ServiceEntry struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewServiceEntry(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *ServiceEntry {
+	d := &ServiceEntry{
+		plus:   plus,
+		logger: logger.WithValues("Own", "ServiceEntry"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *ServiceEntry) Apply() error {
+	names := map[string]bool{}
+	// 开启 perVersion 时版本的 Service 已经可以解析别名域名
+	if r.plus.Spec.Service.IsAliases() && !r.plus.Spec.Service.IsPerVersion() {
+		for _, app := range r.plus.Spec.Apps {
+			obj, err := r.generateAlias(app)
+			if err != nil {
+				return err
+			}
+			if err := r.apply(obj); err != nil {
+				return err
+			}
+			names[obj.Name] = true
+		}
+	}
+
+	for _, egress := range r.plus.Spec.Egress {
+		obj, err := r.generateEgress(egress)
+		if err != nil {
+			return err
+		}
+		if err := r.apply(obj); err != nil {
+			return err
+		}
+		names[obj.Name] = true
+	}
+	return r.deleteUnused(names)
+}
+
+func (r *ServiceEntry) apply(obj *istioclientapiv1.ServiceEntry) error {
+	exist, found, err := r.exist(obj.Name)
+	if err != nil {
+		return err
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!", "Name", obj.Name)
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Hosts, found.Spec.Hosts) ||
+		!reflect.DeepEqual(obj.Spec.Ports, found.Spec.Ports) ||
+		!reflect.DeepEqual(obj.Spec.Location, found.Spec.Location) ||
+		!reflect.DeepEqual(obj.Spec.Resolution, found.Spec.Resolution) ||
+		!reflect.DeepEqual(obj.Spec.Endpoints, found.Spec.Endpoints) ||
+		!reflect.DeepEqual(obj.Spec.ExportTo, found.Spec.ExportTo) ||
+		!reflect.DeepEqual(obj.Spec.WorkloadSelector, found.Spec.WorkloadSelector) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!", "Name", obj.Name)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUnused 删除关闭 aliases、移除的版本或者移除的外部依赖对应的 ServiceEntry，只删除由该 Plus 创建的
+func (r *ServiceEntry) deleteUnused(names map[string]bool) error {
+	list := &istioclientapiv1.ServiceEntryList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(r.plus.GetNamespace()), client.MatchingLabels{"plus": r.plus.GetName()}); err != nil {
+		return err
+	}
+	for _, found := range list.Items {
+		if names[found.Name] || !metav1.IsControlledBy(found, r.plus) {
+			continue
+		}
+		r.logger.Info("Not required, delete it!", "Name", found.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *ServiceEntry) UpdateStatus() error {
+	return nil
+}
+
+func (r *ServiceEntry) Type() string {
+	return "ServiceEntry"
+}
+
+// generateAlias 版本别名域名的 ServiceEntry，通过 workloadSelector 只选择该版本的 Pod
+func (r *ServiceEntry) generateAlias(app *v1.PlusApp) (*istioclientapiv1.ServiceEntry, error) {
+	se := &istioclientapiv1.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetVersionServiceName(app),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istioapiv1.ServiceEntry{
+			Hosts: []string{r.plus.GetVersionHost(app)},
+			Ports: []*istioapiv1.Port{{
+				Number:   uint32(app.Port),
+				Protocol: app.GetIstioProtocol(),
+				Name:     app.GetPortName(),
+			}},
+			Location:         istioapiv1.ServiceEntry_MESH_INTERNAL,
+			Resolution:       istioapiv1.ServiceEntry_STATIC,
+			WorkloadSelector: &istioapiv1.WorkloadSelector{Labels: r.plus.GenerateAppLabels(app)},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, se, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return se, nil
+}
+
+// generateEgress 外部依赖的 ServiceEntry，只导出到当前命名空间，避免影响其他命名空间
+func (r *ServiceEntry) generateEgress(egress *v1.PlusEgress) (*istioclientapiv1.ServiceEntry, error) {
+	ports := make([]*istioapiv1.Port, 0, len(egress.Ports))
+	for _, port := range egress.Ports {
+		ports = append(ports, &istioapiv1.Port{
+			Number:     uint32(port.Number),
+			Protocol:   port.GetIstioProtocol(),
+			Name:       port.GetName(),
+			TargetPort: uint32(port.TargetPort),
+		})
+	}
+	var endpoints []*istioapiv1.WorkloadEntry
+	for _, endpoint := range egress.Endpoints {
+		endpoints = append(endpoints, &istioapiv1.WorkloadEntry{Address: endpoint})
+	}
+
+	se := &istioclientapiv1.ServiceEntry{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      egress.GetResourceName(r.plus),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istioapiv1.ServiceEntry{
+			Hosts:      []string{egress.Host},
+			Ports:      ports,
+			Location:   istioapiv1.ServiceEntry_MESH_EXTERNAL,
+			Resolution: egress.GetResolution(),
+			Endpoints:  endpoints,
+			ExportTo:   []string{"."},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, se, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return se, nil
+}
+
+func (r *ServiceEntry) exist(name string) (bool, *istioclientapiv1.ServiceEntry, error) {
+	found := &istioclientapiv1.ServiceEntry{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
@@ -0,0 +1,130 @@
+package own
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	v1 "clusterplus.io/clusterplus/api/v1"
+	"github.com/go-logr/logr"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+)
+
+type Sidecar struct {
+	plus   *v1.Plus
+	scheme *runtime.Scheme
+	logger logr.Logger
+	client client.Client
+}
+
+func NewSidecar(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *Sidecar {
+	d := &Sidecar{
+		plus:   plus,
+		logger: logger.WithValues("Own", "Sidecar"),
+		scheme: scheme,
+		client: client}
+	return d
+}
+
+// Apply this own resource, create or update
+func (r *Sidecar) Apply() error {
+	obj, err := r.generate()
+	if err != nil {
+		return err
+	}
+
+	exist, found, err := r.exist()
+	if err != nil {
+		return err
+	}
+
+	if obj == nil {
+		if exist && metav1.IsControlledBy(found, r.plus) {
+			r.logger.Info("Not required, delete it!")
+			if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if !exist {
+		r.logger.Info("Not found, create it!")
+		if err := r.client.Create(context.TODO(), obj); err != nil {
+			return err
+		}
+		return nil
+	}
+
+	// 同名的 Sidecar 不是该 Plus 创建的，不能覆盖
+	if !metav1.IsControlledBy(found, r.plus) {
+		return fmt.Errorf("sidecar %s already exists and is not controlled by plus %s", obj.Name, r.plus.GetName())
+	}
+
+	if !reflect.DeepEqual(obj.Spec.WorkloadSelector, found.Spec.WorkloadSelector) ||
+		!reflect.DeepEqual(obj.Spec.Egress, found.Spec.Egress) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!")
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *Sidecar) UpdateStatus() error {
+	return nil
+}
+
+func (r *Sidecar) Type() string {
+	return "Sidecar"
+}
+
+// generate 限制 sidecar 的出口配置，只下发网格内的服务和声明的外部依赖
+func (r *Sidecar) generate() (*istioclientapiv1.Sidecar, error) {
+	hosts := r.plus.GetSidecarHosts()
+	if len(hosts) == 0 {
+		return nil, nil
+	}
+
+	sidecar := &istioclientapiv1.Sidecar{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      r.plus.GetName(),
+			Namespace: r.plus.GetNamespace(),
+			Labels:    r.plus.GenerateLabels(),
+		},
+		Spec: istioapiv1.Sidecar{
+			WorkloadSelector: &istioapiv1.WorkloadSelector{
+				Labels: r.plus.GenerateLabels(),
+			},
+			Egress: []*istioapiv1.IstioEgressListener{{Hosts: hosts}},
+		},
+	}
+
+	// 绑定关系，删除instance会删除底下所有资源
+	if err := controllerutil.SetControllerReference(r.plus, sidecar, r.scheme); err != nil {
+		r.logger.Error(err, "Set controllerReference failed")
+		return nil, err
+	}
+	return sidecar, nil
+}
+
+func (r *Sidecar) exist() (bool, *istioclientapiv1.Sidecar, error) {
+	found := &istioclientapiv1.Sidecar{}
+	err := r.client.Get(context.TODO(), types.NamespacedName{Name: r.plus.GetName(), Namespace: r.plus.GetNamespace()}, found)
+	if err != nil {
+		if errors.IsNotFound(err) {
+			return false, nil, nil
+		}
+		r.logger.Error(err, "Found error")
+		return true, found, err
+	}
+	return true, found, nil
+}
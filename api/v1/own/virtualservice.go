@@ -6,7 +6,6 @@ import (
 	"fmt"
 	"github.com/go-logr/logr"
 	"github.com/golang/protobuf/ptypes/duration"
-	"github.com/golang/protobuf/ptypes/wrappers"
 	istioapiv1 "istio.io/api/networking/v1alpha3"
 	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
 	"k8s.io/apimachinery/pkg/api/errors"
@@ -14,6 +13,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"reflect"
+	"regexp"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
 )
@@ -23,6 +23,8 @@ type VirtualService struct {
 	scheme *runtime.Scheme
 	logger logr.Logger
 	client client.Client
+	// listener 正在生成的网关 listener
+	listener *v1.PlusGatewayListener
 }
 
 func NewVirtualService(plus *v1.Plus, scheme *runtime.Scheme, client client.Client, logger logr.Logger) *VirtualService {
@@ -36,65 +38,72 @@ func NewVirtualService(plus *v1.Plus, scheme *runtime.Scheme, client client.Clie
 
 // Apply this own resource, create or update
 func (r *VirtualService) Apply() error {
-	if r.plus.Spec.Gateway != nil {
+	names := map[string]bool{r.plus.GetName(): true}
+	for _, listener := range r.plus.GetListeners() {
+		r.listener = listener
 		obj, err := r.generate(true)
 		if err != nil {
 			return err
 		}
-
-		exist, found, err := r.exist(true)
-		if err != nil {
+		if err := r.apply(obj); err != nil {
 			return err
 		}
-
-		if !exist {
-			r.logger.Info("Not found, create it!")
-			if err := r.client.Create(context.TODO(), obj); err != nil {
-				return err
-			}
-		} else {
-			if !reflect.DeepEqual(obj.Spec.Hosts, found.Spec.Hosts) ||
-				!reflect.DeepEqual(obj.Spec.Gateways, found.Spec.Gateways) ||
-				!reflect.DeepEqual(obj.Spec.Http, found.Spec.Http) ||
-				!reflect.DeepEqual(obj.Spec.Tcp, found.Spec.Tcp) ||
-				!reflect.DeepEqual(obj.Spec.Tls, found.Spec.Tls) ||
-				!reflect.DeepEqual(obj.Spec.ExportTo, found.Spec.ExportTo) {
-				obj.ResourceVersion = found.ResourceVersion
-				r.logger.Info("Updating!")
-				if err := r.client.Update(context.TODO(), obj); err != nil {
-					return err
-				}
-			}
-		}
+		names[obj.Name] = true
 	}
+	r.listener = nil
 
 	obj, err := r.generate(false)
 	if err != nil {
 		return err
 	}
+	if err := r.apply(obj); err != nil {
+		return err
+	}
+	return r.deleteUnused(names)
+}
 
-	exist, found, err := r.exist(false)
+func (r *VirtualService) apply(obj *istioclientapiv1.VirtualService) error {
+	exist, found, err := r.exist(obj.Name)
 	if err != nil {
 		return err
 	}
 
 	if !exist {
-		r.logger.Info("Not found, create it!")
+		r.logger.Info("Not found, create it!", "Name", obj.Name)
 		if err := r.client.Create(context.TODO(), obj); err != nil {
 			return err
 		}
-	} else {
-		if !reflect.DeepEqual(obj.Spec.Hosts, found.Spec.Hosts) ||
-			!reflect.DeepEqual(obj.Spec.Gateways, found.Spec.Gateways) ||
-			!reflect.DeepEqual(obj.Spec.Http, found.Spec.Http) ||
-			!reflect.DeepEqual(obj.Spec.Tcp, found.Spec.Tcp) ||
-			!reflect.DeepEqual(obj.Spec.Tls, found.Spec.Tls) ||
-			!reflect.DeepEqual(obj.Spec.ExportTo, found.Spec.ExportTo) {
-			obj.ResourceVersion = found.ResourceVersion
-			r.logger.Info("Updating!")
-			if err := r.client.Update(context.TODO(), obj); err != nil {
-				return err
-			}
+		return nil
+	}
+
+	if !reflect.DeepEqual(obj.Spec.Hosts, found.Spec.Hosts) ||
+		!reflect.DeepEqual(obj.Spec.Gateways, found.Spec.Gateways) ||
+		!reflect.DeepEqual(obj.Spec.Http, found.Spec.Http) ||
+		!reflect.DeepEqual(obj.Spec.Tcp, found.Spec.Tcp) ||
+		!reflect.DeepEqual(obj.Spec.Tls, found.Spec.Tls) ||
+		!reflect.DeepEqual(obj.Spec.ExportTo, found.Spec.ExportTo) {
+		obj.ResourceVersion = found.ResourceVersion
+		r.logger.Info("Updating!", "Name", obj.Name)
+		if err := r.client.Update(context.TODO(), obj); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// deleteUnused 删除已经移除的 listener 对应的 VirtualService，只删除由该 Plus 创建的
+func (r *VirtualService) deleteUnused(names map[string]bool) error {
+	list := &istioclientapiv1.VirtualServiceList{}
+	if err := r.client.List(context.TODO(), list, client.InNamespace(r.plus.GetNamespace()), client.MatchingLabels{"plus": r.plus.GetName()}); err != nil {
+		return err
+	}
+	for _, found := range list.Items {
+		if names[found.Name] || !metav1.IsControlledBy(found, r.plus) {
+			continue
+		}
+		r.logger.Info("Not required, delete it!", "Name", found.Name)
+		if err := r.client.Delete(context.TODO(), found); err != nil && !errors.IsNotFound(err) {
+			return err
 		}
 	}
 	return nil
@@ -109,40 +118,9 @@ func (r *VirtualService) Type() string {
 }
 
 func (r *VirtualService) generate(isGateway bool) (*istioclientapiv1.VirtualService, error) {
-	httpRoutes := make([]*istioapiv1.HTTPRoute, 0, len(r.plus.Spec.Apps)+1)
-
-	for _, app := range r.plus.Spec.Apps {
-		httpRoute := &istioapiv1.HTTPRoute{
-			Match:      r.generateMatch(app, isGateway),
-			Rewrite:    r.generateRewrite(isGateway),
-			Route:      r.generateRoute(app),
-			Fault:      r.generateFault(),
-			Retries:    r.generateRetries(),
-			CorsPolicy: r.generateCorsPolicy(isGateway),
-		}
-		if r.plus.Spec.Policy != nil {
-			httpRoute.Timeout = r.plus.Spec.Policy.GetTimeout()
-		}
-		httpRoutes = append(httpRoutes, httpRoute)
-	}
-
-	if isGateway {
-		httpRoute := &istioapiv1.HTTPRoute{
-			Match:      r.generateDefaultMatches(isGateway),
-			Rewrite:    r.generateRewrite(isGateway),
-			Route:      r.generateDefaultRoute(),
-			Fault:      r.generateFault(),
-			Retries:    r.generateRetries(),
-			CorsPolicy: r.generateCorsPolicy(isGateway),
-		}
-		if r.plus.Spec.Policy != nil {
-			httpRoute.Timeout = r.plus.Spec.Policy.GetTimeout()
-		}
-		httpRoutes = append(httpRoutes, httpRoute)
-	}
 	name := r.plus.GetName()
 	if isGateway {
-		name = name + "-gateway"
+		name = r.listener.GetVirtualServiceName(r.plus)
 	}
 
 	vs := &istioclientapiv1.VirtualService{
@@ -154,10 +132,25 @@ func (r *VirtualService) generate(isGateway bool) (*istioclientapiv1.VirtualServ
 		Spec: istioapiv1.VirtualService{
 			Hosts:    r.generateHost(isGateway),
 			Gateways: r.generateGateway(isGateway),
-			Http:     httpRoutes,
 		},
 	}
 
+	switch r.plus.GetRouteType() {
+	case v1.RouteTypeTCP:
+		vs.Spec.Tcp = r.generateTCPRoutes(isGateway)
+	case v1.RouteTypeTLS:
+		vs.Spec.Tls = r.generateTLSRoutes(isGateway)
+	default:
+		vs.Spec.Http = r.generateAllHTTPRoutes(isGateway)
+	}
+
+	// 四层无法区分访问的域名，版本别名的 Service 或 ServiceEntry 只选择该版本的 Pod，不需要路由
+	if !isGateway && r.plus.Spec.Service.IsAliases() && r.plus.GetRouteType() != v1.RouteTypeTCP {
+		for _, app := range r.plus.Spec.Apps {
+			vs.Spec.Hosts = append(vs.Spec.Hosts, r.plus.GetVersionHost(app))
+		}
+	}
+
 	// 绑定关系，删除instance会删除底下所有资源
 	if err := controllerutil.SetControllerReference(r.plus, vs, r.scheme); err != nil {
 		r.logger.Error(err, "Set controllerReference failed")
@@ -166,12 +159,300 @@ func (r *VirtualService) generate(isGateway bool) (*istioclientapiv1.VirtualServ
 	return vs, nil
 }
 
-func (r *VirtualService) exist(isGateway bool) (bool, *istioclientapiv1.VirtualService, error) {
-	name := r.plus.GetName()
+func (r *VirtualService) generateAllHTTPRoutes(isGateway bool) []*istioapiv1.HTTPRoute {
+	httpRoutes := make([]*istioapiv1.HTTPRoute, 0, len(r.plus.Spec.Apps)+1)
+
+	if isGateway {
+		httpRoutes = append(httpRoutes, r.generateRedirectRoutes()...)
+	}
+
+	if isGateway && r.plus.Spec.Gateway.Maintenance.IsEnabled() {
+		// 维护模式下除了放行的请求，其他请求直接返回维护响应
+		return append(httpRoutes, r.generateMaintenanceRoutes()...)
+	}
+
+	if !isGateway && r.plus.Spec.Service.IsAliases() {
+		for _, app := range r.plus.Spec.Apps {
+			httpRoutes = append(httpRoutes, r.generateAliasRoute(app))
+		}
+	}
+
+	for _, app := range r.plus.Spec.Apps {
+		httpRoutes = append(httpRoutes, r.generateHTTPRoutes(app, isGateway)...)
+	}
+
+	if isGateway {
+		httpRoutes = append(httpRoutes, r.generateHTTPRoutes(nil, isGateway)...)
+	}
+	return httpRoutes
+}
+
+// generateTCPRoutes 四层路由无法匹配请求头，网格内按来源版本转发，网关按权重分流
+func (r *VirtualService) generateTCPRoutes(isGateway bool) []*istioapiv1.TCPRoute {
 	if isGateway {
-		name = name + "-gateway"
+		return []*istioapiv1.TCPRoute{{Route: r.generateL4DefaultRoute()}}
+	}
+	routes := make([]*istioapiv1.TCPRoute, 0, len(r.plus.Spec.Apps))
+	for _, app := range r.plus.Spec.Apps {
+		route := &istioapiv1.TCPRoute{Route: r.generateL4Route(app)}
+		if match := r.generateL4Match(app); match != nil {
+			route.Match = []*istioapiv1.L4MatchAttributes{match}
+		}
+		routes = append(routes, route)
+	}
+	return routes
+}
+
+// generateTLSRoutes 透传的 TLS 流量按照 SNI 路由，网格内的 SNI 为服务域名，网关的 SNI 为 listener 的域名
+func (r *VirtualService) generateTLSRoutes(isGateway bool) []*istioapiv1.TLSRoute {
+	if isGateway {
+		return []*istioapiv1.TLSRoute{{
+			Match: []*istioapiv1.TLSMatchAttributes{{SniHosts: r.listener.Hosts}},
+			Route: r.generateL4DefaultRoute(),
+		}}
+	}
+	sniHosts := r.generateHost(false)
+	routes := make([]*istioapiv1.TLSRoute, 0, len(r.plus.Spec.Apps)*2)
+	if r.plus.Spec.Service.IsAliases() {
+		for _, app := range r.plus.Spec.Apps {
+			routes = append(routes, &istioapiv1.TLSRoute{
+				Match: []*istioapiv1.TLSMatchAttributes{{SniHosts: []string{r.plus.GetVersionHost(app)}}},
+				Route: r.generateL4Route(app),
+			})
+		}
+	}
+	for _, app := range r.plus.Spec.Apps {
+		match := &istioapiv1.TLSMatchAttributes{SniHosts: sniHosts}
+		if l4 := r.generateL4Match(app); l4 != nil {
+			match.SourceNamespace = l4.SourceNamespace
+			match.SourceLabels = l4.SourceLabels
+		}
+		routes = append(routes, &istioapiv1.TLSRoute{
+			Match: []*istioapiv1.TLSMatchAttributes{match},
+			Route: r.generateL4Route(app),
+		})
+	}
+	return routes
+}
+
+// generateL4Match 和 http 路由一致，蓝绿版本的请求优先转发到同一个版本
+func (r *VirtualService) generateL4Match(app *v1.PlusApp) *istioapiv1.L4MatchAttributes {
+	if app.Version != "blue" && app.Version != "green" {
+		return nil
+	}
+	return &istioapiv1.L4MatchAttributes{
+		SourceNamespace: r.plus.GetNamespace(),
+		SourceLabels:    r.plus.GenerateVersionLabels(app),
+	}
+}
+
+func (r *VirtualService) generateL4Route(app *v1.PlusApp) []*istioapiv1.RouteDestination {
+	return []*istioapiv1.RouteDestination{
+		{
+			Destination: r.generateDestination(app),
+			Weight:      100,
+		},
+	}
+}
+
+// generateL4DefaultRoute 按照网关的配置流量比例
+func (r *VirtualService) generateL4DefaultRoute() []*istioapiv1.RouteDestination {
+	routeDestinations := make([]*istioapiv1.RouteDestination, 0, len(r.plus.Spec.Apps))
+	for _, app := range r.plus.Spec.Apps {
+		routeDestinations = append(routeDestinations, &istioapiv1.RouteDestination{
+			Destination: r.generateDestination(app),
+			Weight:      r.plus.Spec.Gateway.Weights[app.Version],
+		})
+	}
+	return routeDestinations
+}
+
+func (r *VirtualService) generateDestination(app *v1.PlusApp) *istioapiv1.Destination {
+	return &istioapiv1.Destination{
+		Host: fmt.Sprintf("%s.%s.svc.cluster.local", r.plus.GetName(), r.plus.GetNamespace()),
+		Port: &istioapiv1.PortSelector{
+			Number: uint32(app.Port),
+		},
+		Subset: r.plus.GetAppName(app),
+	}
+}
+
+// generateHTTPRoutes 生成版本的路由，app 为 nil 时生成网关按权重分流的默认路由。
+// 故障注入限定了请求头时，在原路由前插入一条带请求头匹配的故障路由
+func (r *VirtualService) generateHTTPRoutes(app *v1.PlusApp, isGateway bool) []*istioapiv1.HTTPRoute {
+	routes := make([]*istioapiv1.HTTPRoute, 0, 2)
+
+	fault := r.generateFault(app, isGateway)
+	if fault != nil && len(r.plus.Spec.Policy.Fault.HeadersMatch) > 0 {
+		faultRoute := r.generateHTTPRoute(app, isGateway)
+		faultRoute.Match = r.withHeadersMatch(faultRoute.Match, r.plus.Spec.Policy.Fault.HeadersMatch)
+		faultRoute.Fault = fault
+		routes = append(routes, faultRoute)
+		fault = nil
+	}
+
+	route := r.generateHTTPRoute(app, isGateway)
+	route.Fault = fault
+	return append(routes, route)
+}
+
+func (r *VirtualService) generateHTTPRoute(app *v1.PlusApp, isGateway bool) *istioapiv1.HTTPRoute {
+	if app == nil {
+		return &istioapiv1.HTTPRoute{
+			Match:      r.generateDefaultMatches(isGateway),
+			Rewrite:    r.generateRewrite(isGateway),
+			Route:      r.generateDefaultRoute(),
+			Retries:    r.generateRetries(nil),
+			Timeout:    r.generateTimeout(nil, isGateway),
+			CorsPolicy: r.generateCorsPolicy(nil, isGateway),
+			Headers:    r.generateHeaders(nil, isGateway),
+		}
+	}
+	return &istioapiv1.HTTPRoute{
+		Match:      r.generateMatch(app, isGateway),
+		Rewrite:    r.generateRewrite(isGateway),
+		Route:      r.generateRoute(app, isGateway),
+		Retries:    r.generateRetries(app),
+		Timeout:    r.generateTimeout(app, isGateway),
+		CorsPolicy: r.generateCorsPolicy(app, isGateway),
+		Headers:    r.generateHeaders(app, isGateway),
+	}
+}
+
+// generateAliasRoute 访问版本别名域名的请求固定转发到该版本，authority 可能是短域名或者带端口
+func (r *VirtualService) generateAliasRoute(app *v1.PlusApp) *istioapiv1.HTTPRoute {
+	name := regexp.QuoteMeta(r.plus.GetVersionServiceName(app))
+	namespace := regexp.QuoteMeta(r.plus.GetNamespace())
+	authority := fmt.Sprintf(`^%s(\.%s(\.svc(\.cluster\.local)?)?)?(:[0-9]+)?$`, name, namespace)
+	return &istioapiv1.HTTPRoute{
+		Match: []*istioapiv1.HTTPMatchRequest{{
+			Authority: &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Regex{Regex: authority}},
+		}},
+		Route:   r.generateRoute(app, false),
+		Retries: r.generateRetries(app),
+		Timeout: r.generateTimeout(app, false),
+		Headers: r.generateHeaders(app, false),
+	}
+}
+
+// generateRedirectRoutes 生成 https 重定向和前缀迁移的重定向路由
+func (r *VirtualService) generateRedirectRoutes() []*istioapiv1.HTTPRoute {
+	routes := make([]*istioapiv1.HTTPRoute, 0, len(r.plus.Spec.Gateway.Redirects)+1)
+
+	if r.plus.Spec.Gateway.HttpsRedirect {
+		matches := r.generateDefaultMatches(true)
+		for _, match := range matches {
+			match.Scheme = &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Exact{Exact: "http"}}
+		}
+		routes = append(routes, &istioapiv1.HTTPRoute{
+			Match: matches,
+			Redirect: &istioapiv1.HTTPRedirect{
+				Scheme:       "https",
+				RedirectPort: &istioapiv1.HTTPRedirect_DerivePort{DerivePort: istioapiv1.HTTPRedirect_FROM_PROTOCOL_DEFAULT},
+				RedirectCode: 301,
+			},
+		})
+	}
+
+	for _, redirect := range r.plus.Spec.Gateway.Redirects {
+		routes = append(routes, &istioapiv1.HTTPRoute{
+			Match: []*istioapiv1.HTTPMatchRequest{
+				{
+					Uri: &istioapiv1.StringMatch{
+						MatchType: &istioapiv1.StringMatch_Prefix{
+							Prefix: redirect.From,
+						},
+					},
+				},
+			},
+			Redirect: &istioapiv1.HTTPRedirect{
+				Uri:          redirect.To,
+				Authority:    redirect.Authority,
+				RedirectCode: redirect.GetCode(),
+			},
+		})
+	}
+	return routes
+}
+
+// generateMaintenanceRoutes 生成维护模式的路由，放行的请求转发到指定版本，其他请求直接返回维护响应
+func (r *VirtualService) generateMaintenanceRoutes() []*istioapiv1.HTTPRoute {
+	maintenance := r.plus.Spec.Gateway.Maintenance
+	routes := make([]*istioapiv1.HTTPRoute, 0, 2)
+
+	allows := make([]map[string]string, 0, len(maintenance.AllowHeadersMatch)+len(maintenance.AllowSourceIPs))
+	allows = append(allows, maintenance.AllowHeadersMatch...)
+	for _, ip := range maintenance.AllowSourceIPs {
+		allows = append(allows, map[string]string{"x-envoy-external-address": ip})
+	}
+
+	if len(allows) > 0 {
+		var allowApp *v1.PlusApp
+		for _, app := range r.plus.Spec.Apps {
+			if app.Version == maintenance.AllowVersion {
+				allowApp = app
+			}
+		}
+
+		route := r.generateHTTPRoute(allowApp, true)
+		route.Match = make([]*istioapiv1.HTTPMatchRequest, 0, len(allows)*2)
+		for _, headers := range allows {
+			route.Match = append(route.Match, r.withHeadersMatch(r.generateDefaultMatches(true), headers)...)
+		}
+		routes = append(routes, route)
 	}
 
+	route := &istioapiv1.HTTPRoute{
+		Match: r.generateDefaultMatches(true),
+		DirectResponse: &istioapiv1.HTTPDirectResponse{
+			Status: maintenance.GetStatus(),
+		},
+		CorsPolicy: r.generateCorsPolicy(nil, true),
+	}
+	if maintenance.Body != "" {
+		route.DirectResponse.Body = &istioapiv1.HTTPBody{
+			Specifier: &istioapiv1.HTTPBody_String_{String_: maintenance.Body},
+		}
+	}
+	if maintenance.ContentType != "" {
+		route.Headers = &istioapiv1.Headers{
+			Response: &istioapiv1.Headers_HeaderOperations{
+				Set: map[string]string{"content-type": maintenance.ContentType},
+			},
+		}
+	}
+	return append(routes, route)
+}
+
+// withHeadersMatch 在所有匹配条件上追加请求头匹配，没有匹配条件时只匹配请求头
+func (r *VirtualService) withHeadersMatch(matches []*istioapiv1.HTTPMatchRequest, headers map[string]string) []*istioapiv1.HTTPMatchRequest {
+	if len(matches) == 0 {
+		return []*istioapiv1.HTTPMatchRequest{{Headers: r.generateExactHeaders(headers)}}
+	}
+	for _, match := range matches {
+		if match.Headers == nil {
+			match.Headers = make(map[string]*istioapiv1.StringMatch, len(headers))
+		}
+		for k, v := range r.generateExactHeaders(headers) {
+			match.Headers[k] = v
+		}
+	}
+	return matches
+}
+
+func (r *VirtualService) generateExactHeaders(headers map[string]string) map[string]*istioapiv1.StringMatch {
+	matches := make(map[string]*istioapiv1.StringMatch, len(headers))
+	for k, v := range headers {
+		matches[k] = &istioapiv1.StringMatch{
+			MatchType: &istioapiv1.StringMatch_Exact{
+				Exact: v,
+			},
+		}
+	}
+	return matches
+}
+
+func (r *VirtualService) exist(name string) (bool, *istioclientapiv1.VirtualService, error) {
 	found := &istioclientapiv1.VirtualService{}
 	err := r.client.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: r.plus.GetNamespace()}, found)
 	if err != nil {
@@ -188,19 +469,26 @@ func (r *VirtualService) generateHost(isGateway bool) []string {
 	if !isGateway {
 		return []string{fmt.Sprintf("%s.%s.svc.cluster.local", r.plus.GetName(), r.plus.GetNamespace())}
 	}
-	return r.plus.Spec.Gateway.Hosts
-
+	// 委托的 VirtualService 不能设置 hosts 和 gateways，由根 VirtualService 引用
+	if r.listener.Delegate {
+		return nil
+	}
+	return r.listener.Hosts
 }
 
 func (r *VirtualService) generateGateway(isGateway bool) []string {
 	if !isGateway {
 		return []string{"mesh"}
 	}
-	return []string{"istio-system/gateway"}
+	if r.listener.Delegate {
+		return nil
+	}
+	return r.listener.GetGateways()
 }
 
+// generatePrefixPath 当前 listener 的路径前缀
 func (r *VirtualService) generatePrefixPath() string {
-	return r.plus.GeneratePrefixPath()
+	return r.listener.GetPathPrefix(r.plus)
 }
 
 func (r *VirtualService) generateMatch(app *v1.PlusApp, isGateway bool) []*istioapiv1.HTTPMatchRequest {
@@ -220,62 +508,28 @@ func (r *VirtualService) generateMatch(app *v1.PlusApp, isGateway bool) []*istio
 	route := r.plus.Spec.Gateway.Route[app.Version]
 	if route != nil {
 		for _, match := range route.HeadersMatch {
-			headers := make(map[string]*istioapiv1.StringMatch)
-			for k, v := range match {
-				headers[k] = &istioapiv1.StringMatch{
-					MatchType: &istioapiv1.StringMatch_Exact{
-						Exact: v,
-					},
-				}
+			for _, uri := range r.generateURIMatches() {
+				matches = append(matches, &istioapiv1.HTTPMatchRequest{
+					Headers: r.generateExactHeaders(match),
+					Uri:     uri,
+				})
 			}
-			matches = append(matches, []*istioapiv1.HTTPMatchRequest{
-				{
-					Headers: headers,
-					Uri: &istioapiv1.StringMatch{
-						MatchType: &istioapiv1.StringMatch_Prefix{
-							Prefix: fmt.Sprintf("%s/", r.generatePrefixPath()),
-						},
-					},
-				},
-				{
-					Headers: headers,
-					Uri: &istioapiv1.StringMatch{
-						MatchType: &istioapiv1.StringMatch_Prefix{
-							Prefix: fmt.Sprintf("%s", r.generatePrefixPath()),
-						},
-					},
-				},
-			}...)
-
 		}
 	}
 
-	var defaultHeaders = map[string]*istioapiv1.StringMatch{
-		"VERSION": {
-			MatchType: &istioapiv1.StringMatch_Exact{
-				Exact: app.Version,
-			},
-		},
-	}
 	// 匹配默认版本请求头
-	matches = append(matches, []*istioapiv1.HTTPMatchRequest{
-		{
-			Headers: defaultHeaders,
-			Uri: &istioapiv1.StringMatch{
-				MatchType: &istioapiv1.StringMatch_Prefix{
-					Prefix: fmt.Sprintf("%s/", r.generatePrefixPath()),
-				},
-			},
-		},
-		{
-			Headers: defaultHeaders,
-			Uri: &istioapiv1.StringMatch{
-				MatchType: &istioapiv1.StringMatch_Prefix{
-					Prefix: fmt.Sprintf("%s", r.generatePrefixPath()),
+	for _, uri := range r.generateURIMatches() {
+		matches = append(matches, &istioapiv1.HTTPMatchRequest{
+			Headers: map[string]*istioapiv1.StringMatch{
+				"VERSION": {
+					MatchType: &istioapiv1.StringMatch_Exact{
+						Exact: app.Version,
+					},
 				},
 			},
-		},
-	}...)
+			Uri: uri,
+		})
+	}
 
 	return matches
 }
@@ -284,47 +538,49 @@ func (r *VirtualService) generateDefaultMatches(isGateway bool) []*istioapiv1.HT
 	if !isGateway {
 		return nil
 	}
-	matches := make([]*istioapiv1.HTTPMatchRequest, 0, len(r.plus.Spec.Apps)*2)
-	matches = append(matches, []*istioapiv1.HTTPMatchRequest{
+	matches := make([]*istioapiv1.HTTPMatchRequest, 0, 2)
+	for _, uri := range r.generateURIMatches() {
+		matches = append(matches, &istioapiv1.HTTPMatchRequest{Uri: uri})
+	}
+	return matches
+}
+
+// generateURIMatches 网关路由匹配的路径，配置了 gateway.grpc 的 grpc 服务按照服务名和方法匹配，其他服务匹配 <prefix>/ 和 <prefix>
+func (r *VirtualService) generateURIMatches() []*istioapiv1.StringMatch {
+	if r.plus.IsGrpcRoute() {
+		return r.plus.Spec.Gateway.Grpc.GetURIMatches()
+	}
+	return []*istioapiv1.StringMatch{
 		{
-			Uri: &istioapiv1.StringMatch{
-				MatchType: &istioapiv1.StringMatch_Prefix{
-					Prefix: fmt.Sprintf("%s/", r.generatePrefixPath()),
-				},
+			MatchType: &istioapiv1.StringMatch_Prefix{
+				Prefix: fmt.Sprintf("%s/", r.generatePrefixPath()),
 			},
 		},
 		{
-			Uri: &istioapiv1.StringMatch{
-				MatchType: &istioapiv1.StringMatch_Prefix{
-					Prefix: fmt.Sprintf("%s", r.generatePrefixPath()),
-				},
+			MatchType: &istioapiv1.StringMatch_Prefix{
+				Prefix: r.generatePrefixPath(),
 			},
 		},
-	}...)
-	return matches
+	}
 }
 
 func (r *VirtualService) generateRewrite(isGateway bool) *istioapiv1.HTTPRewrite {
-	if !isGateway {
+	// 按照服务名路由时 grpc 的路径为 /package.Service/Method，不能重写
+	if !isGateway || r.plus.IsGrpcRoute() {
 		return nil
 	}
 
 	return &istioapiv1.HTTPRewrite{
-		Uri: "/",
+		Uri: r.listener.GetRewrite(),
 	}
 }
 
-func (r *VirtualService) generateRoute(app *v1.PlusApp) []*istioapiv1.HTTPRouteDestination {
+func (r *VirtualService) generateRoute(app *v1.PlusApp, isGateway bool) []*istioapiv1.HTTPRouteDestination {
 	return []*istioapiv1.HTTPRouteDestination{
 		{
-			Destination: &istioapiv1.Destination{
-				Host: fmt.Sprintf("%s.%s.svc.cluster.local", r.plus.GetName(), r.plus.GetNamespace()),
-				Port: &istioapiv1.PortSelector{
-					Number: uint32(app.Port),
-				},
-				Subset: r.plus.GetAppName(app),
-			},
-			Weight: 100,
+			Destination: r.generateDestination(app),
+			Weight:      100,
+			Headers:     r.generateDestinationHeaders(app, isGateway),
 		},
 	}
 }
@@ -334,36 +590,100 @@ func (r *VirtualService) generateDefaultRoute() []*istioapiv1.HTTPRouteDestinati
 	routeDestinations := make([]*istioapiv1.HTTPRouteDestination, 0, len(r.plus.Spec.Apps))
 	for _, app := range r.plus.Spec.Apps {
 		routeDestinations = append(routeDestinations, &istioapiv1.HTTPRouteDestination{
-			Destination: &istioapiv1.Destination{
-				Host: fmt.Sprintf("%s.%s.svc.cluster.local", r.plus.GetName(), r.plus.GetNamespace()),
-				Port: &istioapiv1.PortSelector{
-					Number: uint32(app.Port),
-				},
-				Subset: r.plus.GetAppName(app),
-			},
-			Weight: r.plus.Spec.Gateway.Weights[app.Version],
+			Destination: r.generateDestination(app),
+			Weight:      r.plus.Spec.Gateway.Weights[app.Version],
+			Headers:     r.generateDestinationHeaders(app, true),
 		},
 		)
 	}
 	return routeDestinations
 }
 
-// generateRetries 生成重试策略
-func (r *VirtualService) generateRetries() *istioapiv1.HTTPRetry {
-	if r.plus.Spec.Policy == nil || r.plus.Spec.Policy.Retries == nil {
+// generateHeaders 生成路由级别的请求头操作，网关配置和版本自定义路由配置合并
+func (r *VirtualService) generateHeaders(app *v1.PlusApp, isGateway bool) *istioapiv1.Headers {
+	if !isGateway {
 		return nil
 	}
+	headers := r.plus.Spec.Gateway.Headers
+	if app != nil {
+		if route := r.plus.Spec.Gateway.Route[app.Version]; route != nil {
+			headers = v1.MergeHeaders(headers, route.Headers)
+		}
+	}
+	return headers.GetHeaders()
+}
+
+// generateDestinationHeaders 生成版本级别的请求头操作，按权重分流时也能带上实际处理请求的版本
+func (r *VirtualService) generateDestinationHeaders(app *v1.PlusApp, isGateway bool) *istioapiv1.Headers {
+	headers := app.Headers
+	if isGateway && r.plus.Spec.Gateway.VersionResponseHeader != "" {
+		headers = v1.MergeHeaders(headers, &v1.PlusHeaders{
+			Response: &v1.PlusHeaderOperations{
+				Set: map[string]string{r.plus.Spec.Gateway.VersionResponseHeader: app.Version},
+			},
+		})
+	}
+	return headers.GetHeaders()
+}
+
+// generateRetries 生成重试策略，版本配置了重试策略时优先使用版本的配置。
+// 长连接模式下显式关闭重试，istio 默认会重试 2 次
+func (r *VirtualService) generateRetries(app *v1.PlusApp) *istioapiv1.HTTPRetry {
+	if r.plus.GetStreaming(app) != nil {
+		return &istioapiv1.HTTPRetry{Attempts: 0}
+	}
+	var retries *v1.PlusPolicyRetries
+	if r.plus.Spec.Policy != nil {
+		retries = r.plus.Spec.Policy.Retries
+	}
+	if app != nil && app.Policy != nil && app.Policy.Retries != nil {
+		retries = app.Policy.Retries
+	}
+	if retries == nil {
+		return nil
+	}
+	retryOn := retries.RetryOn
+	if retryOn == "" && r.plus.IsGrpc() {
+		retryOn = v1.DefaultGrpcRetryOn
+	}
 	return &istioapiv1.HTTPRetry{
-		Attempts:      r.plus.Spec.Policy.Retries.Attempts,
-		PerTryTimeout: r.plus.Spec.Policy.Retries.GetPerTryTimeout(),
-		RetryOn:       r.plus.Spec.Policy.Retries.RetryOn,
+		Attempts:              retries.Attempts,
+		PerTryTimeout:         retries.GetPerTryTimeout(),
+		RetryOn:               retryOn,
+		RetryRemoteLocalities: retries.GetRetryRemoteLocalities(),
 	}
 }
 
-func (r *VirtualService) generateFault() *istioapiv1.HTTPFaultInjection {
+// generateTimeout 生成超时时间，版本配置了超时时间时优先使用版本的配置。
+// grpc 网关路由配置了 maxTimeout 时使用该值，istio 以路由的超时时间作为 grpc-timeout 请求头的上限
+func (r *VirtualService) generateTimeout(app *v1.PlusApp, isGateway bool) *duration.Duration {
+	// 长连接模式下不设置超时，istio 默认不限制
+	if r.plus.GetStreaming(app) != nil {
+		return nil
+	}
+	if isGateway && r.plus.IsGrpcRoute() {
+		if timeout := r.plus.Spec.Gateway.Grpc.GetMaxTimeout(); timeout != nil {
+			return timeout
+		}
+	}
+	if app != nil && app.Policy != nil && app.Policy.Timeout != "" {
+		return app.Policy.GetTimeout()
+	}
+	if r.plus.Spec.Policy == nil {
+		return nil
+	}
+	return r.plus.Spec.Policy.GetTimeout()
+}
+
+// generateFault 生成故障注入，故障不在实验窗口内或者不在生效范围内时返回 nil
+func (r *VirtualService) generateFault(app *v1.PlusApp, isGateway bool) *istioapiv1.HTTPFaultInjection {
 	if r.plus.Spec.Policy == nil || r.plus.Spec.Policy.Fault == nil {
 		return nil
 	}
+	if !r.plus.IsFaultActive() || !r.plus.Spec.Policy.Fault.InScope(app, isGateway) {
+		return nil
+	}
+
 	fault := &istioapiv1.HTTPFaultInjection{}
 	if r.plus.Spec.Policy.Fault.Delay != nil {
 		fault.Delay = &istioapiv1.HTTPFaultInjection_Delay{
@@ -380,39 +700,28 @@ func (r *VirtualService) generateFault() *istioapiv1.HTTPFaultInjection {
 				HttpStatus: r.plus.Spec.Policy.Fault.Abort.HttpStatus,
 			},
 		}
+		if r.plus.Spec.Policy.Fault.Abort.GrpcStatus != "" {
+			fault.Abort.ErrorType = &istioapiv1.HTTPFaultInjection_Abort_GrpcStatus{
+				GrpcStatus: r.plus.Spec.Policy.Fault.Abort.GrpcStatus,
+			}
+		}
 	}
 	return fault
 }
 
-func (r *VirtualService) generateCorsPolicy(isGateway bool) *istioapiv1.CorsPolicy {
-	if !isGateway || r.plus.Spec.Gateway.Cors == nil {
+// generateCorsPolicy 版本自定义路由配置了跨域时覆盖 listener 和网关级别的配置，默认值由 webhook 填充
+func (r *VirtualService) generateCorsPolicy(app *v1.PlusApp, isGateway bool) *istioapiv1.CorsPolicy {
+	if !isGateway {
 		return nil
 	}
-
-	allowOrigins := make([]*istioapiv1.StringMatch, 0, len(r.plus.Spec.Gateway.Cors.AllowOrigins))
-
-	for _, origin := range r.plus.Spec.Gateway.Cors.AllowOrigins {
-		allowOrigins = append(allowOrigins, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Exact{Exact: origin}})
-	}
-
-	if len(r.plus.Spec.Gateway.Cors.AllowMethods) == 0 {
-		r.plus.Spec.Gateway.Cors.AllowMethods = []string{"POST", "GET", "OPTIONS", "DELETE", "PUT"}
-	}
-
-	if len(r.plus.Spec.Gateway.Cors.AllowHeaders) == 0 {
-		r.plus.Spec.Gateway.Cors.AllowHeaders = []string{"Origin", "x-token"}
-	}
-
-	if len(r.plus.Spec.Gateway.Cors.ExposeHeaders) == 0 {
-		r.plus.Spec.Gateway.Cors.ExposeHeaders = nil
+	cors := r.listener.GetCors(r.plus)
+	if app != nil {
+		if route := r.plus.Spec.Gateway.Route[app.Version]; route != nil && route.Cors != nil {
+			cors = route.Cors
+		}
 	}
-
-	return &istioapiv1.CorsPolicy{
-		AllowOrigins:     allowOrigins,
-		AllowHeaders:     r.plus.Spec.Gateway.Cors.AllowHeaders,
-		AllowMethods:     r.plus.Spec.Gateway.Cors.AllowMethods,
-		ExposeHeaders:    r.plus.Spec.Gateway.Cors.ExposeHeaders,
-		MaxAge:           &duration.Duration{Seconds: 60 * 60 * 24},
-		AllowCredentials: &wrappers.BoolValue{Value: true},
+	if r.plus.IsGrpcWeb() {
+		cors = cors.WithGrpcWeb()
 	}
+	return cors.GetCorsPolicy()
 }
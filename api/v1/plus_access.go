@@ -0,0 +1,336 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DefaultGatewayPrincipal istio 默认入口网关的身份
+const DefaultGatewayPrincipal = "cluster.local/ns/istio-system/sa/istio-ingressgateway-service-account"
+
+// DefaultServiceAccountName 没有指定 ServiceAccount 时 Pod 使用的身份
+const DefaultServiceAccountName = "default"
+
+// PlusAccess 描述网格内的访问控制，只能识别开启了 mTLS 的调用方
+type PlusAccess struct {
+	// AllowFrom 允许访问的来源，配置后只有列表中的来源(和网关)可以访问
+	AllowFrom []*PlusAccessSource `json:"allowFrom,omitempty"`
+	// Mtls 该 Plus 的 mTLS 模式 STRICT,PERMISSIVE,DISABLE，为空时使用网格默认配置
+	Mtls string `json:"mtls,omitempty"`
+}
+
+// PlusAccessSource 允许访问的来源，namespaces、serviceAccounts、pluses 满足其一即可
+type PlusAccessSource struct {
+	Namespaces      []string `json:"namespaces,omitempty"`      //命名空间
+	ServiceAccounts []string `json:"serviceAccounts,omitempty"` //<namespace>/<name>，省略命名空间时为 Plus 所在的命名空间
+	Pluses          []string `json:"pluses,omitempty"`          //<namespace>/<name>，省略命名空间时为 Plus 所在的命名空间
+	Paths           []string `json:"paths,omitempty"`           //为空时对所有路径生效
+	Methods         []string `json:"methods,omitempty"`         //为空时对所有方法生效
+}
+
+var mtlsModes = []string{"STRICT", "PERMISSIVE", "DISABLE"}
+
+// ParseNamespacedName 解析 <namespace>/<name>，省略命名空间时使用 namespace
+func ParseNamespacedName(namespace, value string) (string, string) {
+	if i := strings.Index(value, "/"); i >= 0 {
+		return value[:i], value[i+1:]
+	}
+	return namespace, value
+}
+
+// GeneratePrincipal ServiceAccount 在网格中的身份
+func GeneratePrincipal(namespace, serviceAccount string) string {
+	return fmt.Sprintf("cluster.local/ns/%s/sa/%s", namespace, serviceAccount)
+}
+
+// GeneratePrincipals 该 Plus 所有版本 Pod 的身份。
+// 命名空间的 default ServiceAccount 是同命名空间所有 Pod 共用的身份，不能代表该 Plus，使用它的版本会被忽略
+func (r *Plus) GeneratePrincipals() []string {
+	principals := make([]string, 0, len(r.Spec.Apps))
+	found := make(map[string]bool, len(r.Spec.Apps))
+	for _, app := range r.Spec.Apps {
+		sa := r.GetServiceAccountName(app)
+		if sa == "" || sa == DefaultServiceAccountName || found[sa] {
+			continue
+		}
+		found[sa] = true
+		principals = append(principals, GeneratePrincipal(r.GetNamespace(), sa))
+	}
+	return principals
+}
+
+func (r *PlusAccess) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("access")
+
+	if r.Mtls != "" && !containsString(mtlsModes, r.Mtls) {
+		err := field.NotSupported(fldPath.Child("mtls"), r.Mtls, mtlsModes)
+		return apierrors.NewInvalid(PlusKind, "mtls", field.ErrorList{err})
+	}
+
+	// 调用方的身份来自 mTLS 证书
+	if len(r.AllowFrom) > 0 && r.Mtls == "DISABLE" {
+		err := field.Invalid(fldPath.Child("mtls"), r.Mtls, "allowFrom requires mtls")
+		return apierrors.NewInvalid(PlusKind, "mtls", field.ErrorList{err})
+	}
+
+	for i, source := range r.AllowFrom {
+		if err := source.Validate(fldPath.Child("allowFrom").Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PlusAccessSource) Validate(fldPath *field.Path) error {
+	if len(r.Namespaces) == 0 && len(r.ServiceAccounts) == 0 && len(r.Pluses) == 0 {
+		err := field.Required(fldPath, "one of namespaces, serviceAccounts, pluses is required")
+		return apierrors.NewInvalid(PlusKind, "allowFrom", field.ErrorList{err})
+	}
+
+	for i, v := range r.ServiceAccounts {
+		if err := validateNamespacedName(fldPath.Child("serviceAccounts").Index(i), "serviceAccounts", v); err != nil {
+			return err
+		}
+	}
+
+	for i, v := range r.Pluses {
+		if err := validateNamespacedName(fldPath.Child("pluses").Index(i), "pluses", v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func validateNamespacedName(fldPath *field.Path, name, value string) error {
+	namespace, objName := ParseNamespacedName("default", value)
+	if namespace == "" || objName == "" || strings.Contains(objName, "/") {
+		err := field.Invalid(fldPath, value, "must be <namespace>/<name> or <name>")
+		return apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+	}
+	return nil
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// PlusGatewayAccess 描述经过网关访问的黑白名单和 JWT 认证，只对来自网关的请求生效，不影响网格内的调用
+type PlusGatewayAccess struct {
+	AllowIPs []string        `json:"allowIPs,omitempty"` //白名单 IP 或 CIDR，配置后只有白名单内的客户端可以访问
+	DenyIPs  []string        `json:"denyIPs,omitempty"`  //黑名单 IP 或 CIDR
+	Jwt      *PlusGatewayJwt `json:"jwt,omitempty"`
+	// GatewayPrincipals 网关的身份，默认为 istio 入口网关
+	GatewayPrincipals []string `json:"gatewayPrincipals,omitempty"`
+}
+
+type PlusGatewayJwt struct {
+	Issuer               string                       `json:"issuer,omitempty"`
+	Audiences            []string                     `json:"audiences,omitempty"`
+	JwksUri              string                       `json:"jwksUri,omitempty"`              //jwks 地址
+	Jwks                 string                       `json:"jwks,omitempty"`                 //内联的 jwks
+	JwksFrom             *corev1.ConfigMapKeySelector `json:"jwksFrom,omitempty"`             //从同命名空间的 ConfigMap 读取 jwks，controller 会给 ConfigMap 添加 clusterplus.io/config label 用于监听变化
+	ForwardOriginalToken bool                         `json:"forwardOriginalToken,omitempty"` //是否将 token 转发给应用
+	// Rules 需要认证的路径和必须的 claims，路径为应用收到的路径(去掉网关前缀后)。
+	// 没有配置时只校验携带了的 token，不强制要求 token
+	Rules []*PlusGatewayJwtRule `json:"rules,omitempty"`
+}
+
+type PlusGatewayJwtRule struct {
+	Paths   []string            `json:"paths,omitempty"`   //为空时对所有路径生效
+	Methods []string            `json:"methods,omitempty"` //为空时对所有方法生效
+	Claims  map[string][]string `json:"claims,omitempty"`  //claim 的值必须在列表中
+}
+
+// AllowFromPlusesField 在 allowFrom 中引用的 Plus 的字段索引，值为 <namespace>/<name>，被引用的 Plus 身份变化后更新访问策略
+const AllowFromPlusesField = "spec.access.allowFrom.pluses"
+
+// GetAllowFromPluses allowFrom 中引用的 Plus，值为 <namespace>/<name>
+func (r *Plus) GetAllowFromPluses() []string {
+	if r.Spec.Access == nil {
+		return nil
+	}
+	pluses := make([]string, 0)
+	for _, source := range r.Spec.Access.AllowFrom {
+		for _, p := range source.Pluses {
+			ns, name := ParseNamespacedName(r.GetNamespace(), p)
+			if key := fmt.Sprintf("%s/%s", ns, name); !containsString(pluses, key) {
+				pluses = append(pluses, key)
+			}
+		}
+	}
+	return pluses
+}
+
+// IndexAllowFromPluses AllowFromPlusesField 的索引函数
+func IndexAllowFromPluses(obj client.Object) []string {
+	plus, ok := obj.(*Plus)
+	if !ok {
+		return nil
+	}
+	return plus.GetAllowFromPluses()
+}
+
+// SetupAllowFromPlusesIndex 注册 AllowFromPlusesField 索引
+func SetupAllowFromPlusesIndex(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &Plus{}, AllowFromPlusesField, IndexAllowFromPluses)
+}
+
+// JwksConfigMapField Plus 引用的 jwks ConfigMap 的字段索引，值为同命名空间的 ConfigMap 名称，ConfigMap 变化后重新生成 RequestAuthentication
+const JwksConfigMapField = "spec.gateway.access.jwt.jwksFrom.name"
+
+// ConfigMapLabel controller 只缓存和 watch 带有该 label 的 ConfigMap，值为 ConfigMap 的用途。
+// 限流配置由 controller 创建时添加，jwksFrom 引用的 ConfigMap 在第一次读取时由 controller 添加
+const ConfigMapLabel = "clusterplus.io/config"
+
+const (
+	ConfigMapRateLimit = "ratelimit"
+	ConfigMapJwks      = "jwks"
+)
+
+// GetJwksConfigMap jwksFrom 引用的 ConfigMap，没有引用时返回空
+func (r *Plus) GetJwksConfigMap() string {
+	if r.Spec.Gateway == nil || r.Spec.Gateway.Access == nil || r.Spec.Gateway.Access.Jwt == nil ||
+		r.Spec.Gateway.Access.Jwt.JwksFrom == nil {
+		return ""
+	}
+	return r.Spec.Gateway.Access.Jwt.JwksFrom.Name
+}
+
+// IndexJwksConfigMap JwksConfigMapField 的索引函数
+func IndexJwksConfigMap(obj client.Object) []string {
+	plus, ok := obj.(*Plus)
+	if !ok || plus.GetJwksConfigMap() == "" {
+		return nil
+	}
+	return []string{plus.GetJwksConfigMap()}
+}
+
+// SetupJwksConfigMapIndex 注册 JwksConfigMapField 索引
+func SetupJwksConfigMapIndex(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &Plus{}, JwksConfigMapField, IndexJwksConfigMap)
+}
+
+func (r *PlusGatewayAccess) GetGatewayPrincipals() []string {
+	if len(r.GatewayPrincipals) == 0 {
+		return []string{DefaultGatewayPrincipal}
+	}
+	return r.GatewayPrincipals
+}
+
+// validateGatewayPrincipals 网关的身份只能从默认网关推断，listener 使用其他网关时，
+// 网格内的访问控制和网关的黑白名单需要显式配置 gatewayPrincipals，否则来自该网关的请求会被拒绝或绕过黑白名单
+func (r *Plus) validateGatewayPrincipals(fldPath *field.Path) error {
+	if r.Spec.Gateway == nil {
+		return nil
+	}
+	access := r.Spec.Gateway.Access
+	if access != nil && len(access.GatewayPrincipals) > 0 {
+		return nil
+	}
+	if access == nil && (r.Spec.Access == nil || len(r.Spec.Access.AllowFrom) == 0) {
+		return nil
+	}
+	for _, listener := range r.GetListeners() {
+		for _, gateway := range listener.GetGateways() {
+			if gateway != DefaultGateway {
+				err := field.Required(fldPath.Child("gateway", "access", "gatewayPrincipals"),
+					fmt.Sprintf("gatewayPrincipals is required when listener uses gateway %s", gateway))
+				return apierrors.NewInvalid(PlusKind, "gatewayPrincipals", field.ErrorList{err})
+			}
+		}
+	}
+	return nil
+}
+
+func (r *PlusGatewayAccess) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("access")
+
+	for i, ip := range r.AllowIPs {
+		if err := validateIPBlock(fldPath.Child("allowIPs").Index(i), "allowIPs", ip); err != nil {
+			return err
+		}
+	}
+
+	for i, ip := range r.DenyIPs {
+		if err := validateIPBlock(fldPath.Child("denyIPs").Index(i), "denyIPs", ip); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Jwt; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PlusGatewayJwt) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("jwt")
+
+	if r.Issuer == "" {
+		err := field.Invalid(fldPath.Child("issuer"), r.Issuer, "issuer can't be empty")
+		return apierrors.NewInvalid(PlusKind, "issuer", field.ErrorList{err})
+	}
+
+	sources := 0
+	if r.JwksUri != "" {
+		sources++
+	}
+	if r.Jwks != "" {
+		sources++
+	}
+	if r.JwksFrom != nil {
+		sources++
+	}
+	if sources > 1 {
+		err := field.Invalid(fldPath, sources, "only one of jwksUri, jwks, jwksFrom can be set")
+		return apierrors.NewInvalid(PlusKind, "jwks", field.ErrorList{err})
+	}
+
+	if e := r.JwksFrom; e != nil && (e.Name == "" || e.Key == "") {
+		err := field.Invalid(fldPath.Child("jwksFrom"), e, "name and key can't be empty")
+		return apierrors.NewInvalid(PlusKind, "jwksFrom", field.ErrorList{err})
+	}
+
+	for i, rule := range r.Rules {
+		for k, v := range rule.Claims {
+			if k == "" || len(v) == 0 {
+				err := field.Invalid(fldPath.Child("rules").Index(i).Child("claims").Key(k), v, "claim name and values can't be empty")
+				return apierrors.NewInvalid(PlusKind, "claims", field.ErrorList{err})
+			}
+		}
+	}
+
+	return nil
+}
+
+// validateIPBlock 校验 IP 或 CIDR
+func validateIPBlock(fldPath *field.Path, name, value string) error {
+	if strings.Contains(value, "/") {
+		if _, _, err := net.ParseCIDR(value); err != nil {
+			err := field.Invalid(fldPath, value, err.Error())
+			return apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+		}
+		return nil
+	}
+	if net.ParseIP(value) == nil {
+		err := field.Invalid(fldPath, value, "must be a valid IP or CIDR")
+		return apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+	}
+	return nil
+}
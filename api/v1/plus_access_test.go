@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidAccess(t *testing.T) {
+	tests := []struct {
+		r     PlusAccess
+		isErr bool
+	}{
+		{r: PlusAccess{Mtls: "STRICT", AllowFrom: []*PlusAccessSource{{Namespaces: []string{"web"}}, {Pluses: []string{"svc-b", "other/svc-c"}, Paths: []string{"/api/*"}, Methods: []string{"GET"}}}}, isErr: false},
+		{r: PlusAccess{AllowFrom: []*PlusAccessSource{{ServiceAccounts: []string{"web/frontend"}}}}, isErr: false},
+		{r: PlusAccess{Mtls: "PERMISSIVE"}, isErr: false},
+		{r: PlusAccess{Mtls: "ON"}, isErr: true},
+		{r: PlusAccess{Mtls: "DISABLE", AllowFrom: []*PlusAccessSource{{Namespaces: []string{"web"}}}}, isErr: true},
+		{r: PlusAccess{AllowFrom: []*PlusAccessSource{{Paths: []string{"/api"}}}}, isErr: true},
+		{r: PlusAccess{AllowFrom: []*PlusAccessSource{{Pluses: []string{"web/"}}}}, isErr: true},
+		{r: PlusAccess{AllowFrom: []*PlusAccessSource{{ServiceAccounts: []string{"a/b/c"}}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestParseNamespacedName(t *testing.T) {
+	namespace, name := ParseNamespacedName("default", "svc-b")
+	require.Equal(t, "default", namespace)
+	require.Equal(t, "svc-b", name)
+
+	namespace, name = ParseNamespacedName("default", "web/svc-c")
+	require.Equal(t, "web", namespace)
+	require.Equal(t, "svc-c", name)
+	require.Equal(t, "cluster.local/ns/web/sa/svc-c", GeneratePrincipal(namespace, name))
+}
+
+func TestValidGatewayPrincipals(t *testing.T) {
+	allowFrom := &PlusAccess{AllowFrom: []*PlusAccessSource{{Namespaces: []string{"web"}}}}
+	internal := []*PlusGatewayListener{{Name: "internal", Hosts: []string{"api.internal"}, Gateways: []string{"istio-system/internal-gateway"}}}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: Plus{Spec: PlusSpec{Access: allowFrom, Gateway: &PlusGateway{Hosts: []string{"api.a.com"}}}}, isErr: false},
+		{r: Plus{Spec: PlusSpec{Gateway: &PlusGateway{Listeners: internal}}}, isErr: false},
+		{r: Plus{Spec: PlusSpec{Access: allowFrom, Gateway: &PlusGateway{Listeners: internal}}}, isErr: true},
+		{r: Plus{Spec: PlusSpec{Gateway: &PlusGateway{Listeners: internal, Access: &PlusGatewayAccess{DenyIPs: []string{"1.1.1.1"}}}}}, isErr: true},
+		{r: Plus{Spec: PlusSpec{Access: allowFrom, Gateway: &PlusGateway{Listeners: internal,
+			Access: &PlusGatewayAccess{GatewayPrincipals: []string{"cluster.local/ns/istio-system/sa/internal-gateway"}}}}}, isErr: false},
+	}
+	for _, test := range tests {
+		err := test.r.validateGatewayPrincipals(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestIndexAllowFromPluses(t *testing.T) {
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "default"}}
+	require.Nil(t, IndexAllowFromPluses(plus))
+
+	plus.Spec.Access = &PlusAccess{AllowFrom: []*PlusAccessSource{
+		{Pluses: []string{"svc-b", "web/svc-c"}},
+		{Pluses: []string{"default/svc-b"}, Methods: []string{"GET"}},
+	}}
+	require.Equal(t, []string{"default/svc-b", "web/svc-c"}, IndexAllowFromPluses(plus))
+}
+
+func TestIndexJwksConfigMap(t *testing.T) {
+	plus := &Plus{}
+	require.Nil(t, IndexJwksConfigMap(plus))
+
+	plus.Spec.Gateway = &PlusGateway{Access: &PlusGatewayAccess{Jwt: &PlusGatewayJwt{Issuer: "https://auth",
+		JwksFrom: &corev1.ConfigMapKeySelector{LocalObjectReference: corev1.LocalObjectReference{Name: "jwks"}, Key: "jwks.json"}}}}
+	require.Equal(t, []string{"jwks"}, IndexJwksConfigMap(plus))
+}
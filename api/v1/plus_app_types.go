@@ -5,7 +5,9 @@ import (
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
+	"strings"
 )
 
 type PlusApp struct {
@@ -33,6 +35,24 @@ type PlusApp struct {
 	LogPath                       string                        `json:"logPath,omitempty"`
 	Scale                         PlusScale                     `json:"scale,omitempty"`
 	TerminationGracePeriodSeconds int64                         `json:"terminationGracePeriodSeconds,omitempty"`
+	// Headers 转发到该版本时的请求头/响应头操作，网格内和网关都会生效
+	Headers *PlusHeaders `json:"headers,omitempty"`
+	// Policy 该版本的网络策略，覆盖 spec.policy 中对应的配置
+	Policy *PlusAppPolicy `json:"policy,omitempty"`
+	// ServiceAccount Pod 使用的 ServiceAccount，没有配置时使用命名空间的 default，配置为 {} 时创建所有版本共用的 <plus>
+	ServiceAccount *PlusServiceAccount `json:"serviceAccount,omitempty"`
+	// Spread Pod 按照可用区或节点分散部署的预设，复杂的场景仍然可以使用 affinity
+	Spread *PlusAppSpread `json:"spread,omitempty"`
+	// Rollout 滚动更新的参数，如 maxUnavailable 为 0 实现无中断更新
+	Rollout *PlusAppRollout `json:"rollout,omitempty"`
+}
+
+// PlusServiceAccount 默认创建所有版本共用的 <plus> ServiceAccount，
+// perVersion 时为该版本单独创建 <plus>-<version>，设置 name 时引用已经存在的 ServiceAccount
+type PlusServiceAccount struct {
+	Name        string            `json:"name,omitempty"`        //引用已经存在的 ServiceAccount，不会创建
+	PerVersion  bool              `json:"perVersion,omitempty"`  //为该版本单独创建
+	Annotations map[string]string `json:"annotations,omitempty"` //创建的 ServiceAccount 的 annotations，如云厂商的 workload identity
 }
 
 type PlusAppProbe struct {
@@ -69,10 +89,111 @@ func (r *PlusApp) Validate(fldPath *field.Path) error {
 		return apierrors.NewInvalid(PlusKind, "port", field.ErrorList{err})
 	}
 
-	if r.Protocol != "http" && r.Protocol != "grpc" && r.Protocol != "none" {
-		err := field.Invalid(fldPath.Child("protocol"), r.Protocol, fmt.Sprintf("protocol must (http or grpc)"))
+	if !containsString(supportedProtocols, r.Protocol) {
+		err := field.NotSupported(fldPath.Child("protocol"), r.Protocol, supportedProtocols)
 		return apierrors.NewInvalid(PlusKind, "protocol", field.ErrorList{err})
 	}
 
+	if e := r.Headers; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Policy; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.ServiceAccount; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Spread; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Rollout; e != nil {
+		if err := e.Validate(fldPath, r.RollingUpdateType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *PlusServiceAccount) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("serviceAccount")
+
+	if r.Name == "" {
+		return nil
+	}
+
+	if errs := validation.IsDNS1123Subdomain(r.Name); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("name"), r.Name, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+	}
+
+	if r.PerVersion || len(r.Annotations) > 0 {
+		err := field.Invalid(fldPath.Child("name"), r.Name, "perVersion and annotations can't be set when referencing an existing service account")
+		return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+	}
+	return nil
+}
+
+// GetServiceAccountName 该版本 Pod 使用的 ServiceAccount，为空时使用命名空间的 default。
+// 创建 ServiceAccount 需要显式配置，升级时不会修改已有 Deployment 的身份
+func (r *Plus) GetServiceAccountName(app *PlusApp) string {
+	sa := app.ServiceAccount
+	if sa == nil {
+		return ""
+	}
+	if sa.Name != "" {
+		return sa.Name
+	}
+	if sa.PerVersion {
+		return r.GetAppName(app)
+	}
+	return r.GetName()
+}
+
+// GenerateServiceAccounts 需要创建的 ServiceAccount 和它们的 annotations，共用的 ServiceAccount 合并各版本的 annotations
+func (r *Plus) GenerateServiceAccounts() map[string]map[string]string {
+	serviceAccounts := make(map[string]map[string]string)
+	for _, app := range r.Spec.Apps {
+		if app.ServiceAccount == nil || app.ServiceAccount.Name != "" {
+			continue
+		}
+		name := r.GetServiceAccountName(app)
+		if _, ok := serviceAccounts[name]; !ok {
+			serviceAccounts[name] = make(map[string]string)
+		}
+		for k, v := range app.ServiceAccount.Annotations {
+			serviceAccounts[name][k] = v
+		}
+	}
+	return serviceAccounts
+}
+
+// validateServiceAccounts 共用 ServiceAccount 的版本不能配置冲突的 annotations
+func (r *Plus) validateServiceAccounts(fldPath *field.Path) error {
+	annotations := make(map[string]string)
+	for i, app := range r.Spec.Apps {
+		if app.ServiceAccount == nil || app.ServiceAccount.Name != "" || app.ServiceAccount.PerVersion {
+			continue
+		}
+		for k, v := range app.ServiceAccount.Annotations {
+			if old, ok := annotations[k]; ok && old != v {
+				err := field.Invalid(fldPath.Child("apps").Index(i).Child("serviceAccount", "annotations").Key(k), v, fmt.Sprintf("conflicts with %s of the shared service account", old))
+				return apierrors.NewInvalid(PlusKind, "annotations", field.ErrorList{err})
+			}
+			annotations[k] = v
+		}
+	}
 	return nil
 }
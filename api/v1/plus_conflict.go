@@ -0,0 +1,137 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// GatewayHostsField Plus 网关域名的字段索引，用于查找使用相同域名的 Plus
+const GatewayHostsField = "spec.gateway.hosts"
+
+// ConditionConflict 和其他 Plus 的域名和路径前缀冲突
+const ConditionConflict = "Conflict"
+
+// PlusGatewayEndpoint 网关上的一个入口，同一个 istio 网关上相同域名的路径前缀不能重叠
+type PlusGatewayEndpoint struct {
+	Host     string
+	Prefix   string
+	Gateways []string //<namespace>/<name>，不同命名空间的同名网关是不同的网关
+	Delegate bool     //由根 VirtualService 按照最长前缀优先委托
+}
+
+// GetGatewayEndpoints 所有 listener 的入口
+func (r *Plus) GetGatewayEndpoints() []PlusGatewayEndpoint {
+	endpoints := make([]PlusGatewayEndpoint, 0)
+	for _, listener := range r.GetListeners() {
+		for _, host := range listener.Hosts {
+			for _, prefix := range listener.GetRoutePrefixes(r) {
+				endpoints = append(endpoints, PlusGatewayEndpoint{
+					Host:     host,
+					Prefix:   prefix,
+					Gateways: listener.GetNamespacedGateways(r),
+					Delegate: listener.Delegate,
+				})
+			}
+		}
+	}
+	return endpoints
+}
+
+// GetGatewayHosts 所有 listener 的域名，去重
+func (r *Plus) GetGatewayHosts() []string {
+	found := make(map[string]bool)
+	hosts := make([]string, 0)
+	for _, endpoint := range r.GetGatewayEndpoints() {
+		if !found[endpoint.Host] {
+			found[endpoint.Host] = true
+			hosts = append(hosts, endpoint.Host)
+		}
+	}
+	return hosts
+}
+
+// IndexGatewayHosts GatewayHostsField 的索引函数
+func IndexGatewayHosts(obj client.Object) []string {
+	plus, ok := obj.(*Plus)
+	if !ok {
+		return nil
+	}
+	return plus.GetGatewayHosts()
+}
+
+// SetupGatewayHostsIndex 注册 GatewayHostsField 索引，controller 和 webhook 共用，只能注册一次
+func SetupGatewayHostsIndex(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &Plus{}, GatewayHostsField, IndexGatewayHosts)
+}
+
+// Overlaps 同一个 istio 网关上相同域名的前缀存在包含关系。
+// 网关同时匹配 <prefix> 和 <prefix>/，所以 /svc 也会匹配到 /svc2。
+// 都使用委托时根 VirtualService 按照最长前缀优先转发，只有相同的前缀才会冲突
+func (e PlusGatewayEndpoint) Overlaps(other PlusGatewayEndpoint) bool {
+	if e.Host != other.Host {
+		return false
+	}
+	if e.Delegate && other.Delegate {
+		if e.Prefix != other.Prefix {
+			return false
+		}
+	} else if !strings.HasPrefix(e.Prefix, other.Prefix) && !strings.HasPrefix(other.Prefix, e.Prefix) {
+		return false
+	}
+	for _, gateway := range e.Gateways {
+		if containsString(other.Gateways, gateway) {
+			return true
+		}
+	}
+	return false
+}
+
+// FindConflicts 和其他 Plus 冲突的入口描述，忽略自身
+func (r *Plus) FindConflicts(others []Plus) []string {
+	endpoints := r.GetGatewayEndpoints()
+	conflicts := make([]string, 0)
+	for i := range others {
+		other := &others[i]
+		if other.GetNamespace() == r.GetNamespace() && other.GetName() == r.GetName() {
+			continue
+		}
+		for _, e := range endpoints {
+			for _, o := range other.GetGatewayEndpoints() {
+				if e.Overlaps(o) {
+					conflicts = append(conflicts, fmt.Sprintf("%s%s overlaps %s%s of %s/%s", e.Host, e.Prefix, o.Host, o.Prefix, other.GetNamespace(), other.GetName()))
+				}
+			}
+		}
+	}
+	sort.Strings(conflicts)
+	return conflicts
+}
+
+// ListConflicts 通过域名索引查询使用相同域名的 Plus，返回冲突描述
+func (r *Plus) ListConflicts(ctx context.Context, c client.Reader) ([]string, error) {
+	others := make([]Plus, 0)
+	for _, host := range r.GetGatewayHosts() {
+		list := &PlusList{}
+		if err := c.List(ctx, list, client.MatchingFields{GatewayHostsField: host}); err != nil {
+			return nil, err
+		}
+		others = append(others, list.Items...)
+	}
+
+	// 多个域名可能查询到同一个 Plus
+	found := make(map[string]bool, len(others))
+	unique := make([]Plus, 0, len(others))
+	for _, other := range others {
+		key := other.GetNamespace() + "/" + other.GetName()
+		if !found[key] {
+			found[key] = true
+			unique = append(unique, other)
+		}
+	}
+	return r.FindConflicts(unique), nil
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestFindConflicts(t *testing.T) {
+	newPlus := func(name, prefix string, hosts ...string) Plus {
+		return Plus{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default"},
+			Spec:       PlusSpec{Gateway: &PlusGateway{Hosts: hosts, PathPrefix: &prefix}},
+		}
+	}
+
+	plus := newPlus("svc", "/svc", "api.a.com")
+	tests := []struct {
+		other    Plus
+		conflict bool
+	}{
+		{other: newPlus("svc-v2", "/svc/v2", "api.a.com"), conflict: true},
+		{other: newPlus("svc2", "/svc2", "api.a.com"), conflict: true},
+		{other: newPlus("root", "", "api.a.com"), conflict: true},
+		{other: newPlus("order", "/order", "api.a.com"), conflict: false},
+		{other: newPlus("svc-b", "/svc", "api.b.com"), conflict: false},
+		{other: newPlus("svc", "/svc", "api.a.com"), conflict: false},
+	}
+	for _, test := range tests {
+		conflicts := plus.FindConflicts([]Plus{test.other})
+		require.Equal(t, test.conflict, len(conflicts) > 0, test.other.Name)
+	}
+
+	// 不同的 istio 网关上相同的域名不冲突
+	internal := newPlus("internal", "/svc", "api.a.com")
+	internal.Spec.Gateway.Hosts = nil
+	internal.Spec.Gateway.Listeners = []*PlusGatewayListener{{Name: "internal", Hosts: []string{"api.a.com"}, Gateways: []string{"istio-system/internal-gateway"}}}
+	require.Empty(t, plus.FindConflicts([]Plus{internal}))
+
+	// 网关按照 Plus 所在的命名空间补全后比较
+	gateway := func(namespace, gw string) Plus {
+		p := newPlus("gw-"+namespace, "/svc", "api.a.com")
+		p.Namespace = namespace
+		p.Spec.Gateway.Hosts = nil
+		p.Spec.Gateway.Listeners = []*PlusGatewayListener{{Name: "gw", Hosts: []string{"api.a.com"}, Gateways: []string{gw}}}
+		return p
+	}
+	gwA := gateway("a", "ingressgateway")
+	require.Empty(t, gwA.FindConflicts([]Plus{gateway("b", "ingressgateway")}))
+	require.NotEmpty(t, gwA.FindConflicts([]Plus{gateway("b", "a/ingressgateway")}))
+	require.NotEmpty(t, plus.FindConflicts([]Plus{gateway("istio-system", "gateway")}))
+
+	// 都使用委托时只有相同的前缀冲突
+	delegate := func(name, prefix string) Plus {
+		p := newPlus(name, prefix, "api.a.com")
+		p.Spec.Gateway.Delegate = true
+		return p
+	}
+	svcC := delegate("svc-c", "/svc-c")
+	require.Empty(t, svcC.FindConflicts([]Plus{delegate("svc-c-sub", "/svc-c-sub")}))
+	require.NotEmpty(t, svcC.FindConflicts([]Plus{delegate("svc-c-dup", "/svc-c")}))
+	require.NotEmpty(t, svcC.FindConflicts([]Plus{newPlus("svc-c-sub", "/svc-c-sub", "api.a.com")}))
+
+	require.Equal(t, []string{"api.a.com"}, IndexGatewayHosts(&plus))
+}
@@ -0,0 +1,109 @@
+package v1
+
+import (
+	"regexp"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/wrappers"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+var (
+	DefaultCorsAllowMethods = []string{"POST", "GET", "OPTIONS", "DELETE", "PUT"}
+	DefaultCorsAllowHeaders = []string{"Origin", "x-token"}
+)
+
+const DefaultCorsMaxAge = "24h"
+
+// PlusGatewayCors 跨域配置，origin 满足 allowOrigins、allowOriginPrefixes、allowOriginRegexes 其一即可
+type PlusGatewayCors struct {
+	AllowOrigins        []string `json:"allowOrigins,omitempty"`        //精确匹配
+	AllowOriginPrefixes []string `json:"allowOriginPrefixes,omitempty"` //前缀匹配
+	AllowOriginRegexes  []string `json:"allowOriginRegexes,omitempty"`  //正则匹配(RE2)
+	AllowMethods        []string `json:"allowMethods,omitempty"`
+	AllowHeaders        []string `json:"allowHeaders,omitempty"`
+	ExposeHeaders       []string `json:"exposeHeaders,omitempty"`
+	AllowCredentials    *bool    `json:"allowCredentials,omitempty"` //默认 true
+	MaxAge              string   `json:"maxAge,omitempty"`           //预检请求的缓存时间，默认 24h
+}
+
+// Default 填充默认值，由 webhook 调用，生成 CorsPolicy 时也会对没有设置的字段使用默认值
+func (r *PlusGatewayCors) Default() {
+	if len(r.AllowMethods) == 0 {
+		r.AllowMethods = append([]string{}, DefaultCorsAllowMethods...)
+	}
+	if len(r.AllowHeaders) == 0 {
+		r.AllowHeaders = append([]string{}, DefaultCorsAllowHeaders...)
+	}
+	if r.AllowCredentials == nil {
+		allowCredentials := true
+		r.AllowCredentials = &allowCredentials
+	}
+	if r.MaxAge == "" {
+		r.MaxAge = DefaultCorsMaxAge
+	}
+}
+
+// GetCorsPolicy 转换为 istio 的 CorsPolicy，没有设置的字段使用默认值(webhook 生效前创建的 Plus 没有默认值)，不修改原配置
+func (r *PlusGatewayCors) GetCorsPolicy() *istioapiv1.CorsPolicy {
+	if r == nil {
+		return nil
+	}
+	r = r.DeepCopy()
+	r.Default()
+
+	allowOrigins := make([]*istioapiv1.StringMatch, 0, len(r.AllowOrigins)+len(r.AllowOriginPrefixes)+len(r.AllowOriginRegexes))
+	for _, origin := range r.AllowOrigins {
+		allowOrigins = append(allowOrigins, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Exact{Exact: origin}})
+	}
+	for _, origin := range r.AllowOriginPrefixes {
+		allowOrigins = append(allowOrigins, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Prefix{Prefix: origin}})
+	}
+	for _, origin := range r.AllowOriginRegexes {
+		allowOrigins = append(allowOrigins, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Regex{Regex: origin}})
+	}
+
+	policy := &istioapiv1.CorsPolicy{
+		AllowOrigins:  allowOrigins,
+		AllowMethods:  r.AllowMethods,
+		AllowHeaders:  r.AllowHeaders,
+		ExposeHeaders: r.ExposeHeaders,
+		MaxAge:        parseDuration(r.MaxAge),
+	}
+	policy.AllowCredentials = &wrappers.BoolValue{Value: *r.AllowCredentials}
+	return policy
+}
+
+func (r *PlusGatewayCors) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("cors")
+
+	for i, prefix := range r.AllowOriginPrefixes {
+		if prefix == "" {
+			err := field.Invalid(fldPath.Child("allowOriginPrefixes").Index(i), prefix, "prefix can't be empty")
+			return apierrors.NewInvalid(PlusKind, "allowOriginPrefixes", field.ErrorList{err})
+		}
+	}
+
+	for i, regex := range r.AllowOriginRegexes {
+		if _, err := regexp.Compile(regex); err != nil {
+			err := field.Invalid(fldPath.Child("allowOriginRegexes").Index(i), regex, err.Error())
+			return apierrors.NewInvalid(PlusKind, "allowOriginRegexes", field.ErrorList{err})
+		}
+	}
+
+	if r.MaxAge != "" {
+		d, err := time.ParseDuration(r.MaxAge)
+		if err != nil {
+			err := field.Invalid(fldPath.Child("maxAge"), r.MaxAge, err.Error())
+			return apierrors.NewInvalid(PlusKind, "maxAge", field.ErrorList{err})
+		}
+		if d < 0 {
+			err := field.Invalid(fldPath.Child("maxAge"), r.MaxAge, "maxAge must >= 0")
+			return apierrors.NewInvalid(PlusKind, "maxAge", field.ErrorList{err})
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package v1
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/util/validation"
+)
+
+// RootVirtualServiceLabel 根 VirtualService 的标记，值为域名的摘要，域名可能包含 * 或者超过 label 的长度限制，
+// 完整的域名从 spec.hosts 中读取
+const RootVirtualServiceLabel = "clusterplus.io/root-host"
+
+// PlusDelegateRoute 根 VirtualService 中的一条委托路由
+type PlusDelegateRoute struct {
+	Prefix    string //路径前缀，为空时匹配所有请求
+	Namespace string //委托的 VirtualService 所在的命名空间
+	Name      string //委托的 VirtualService 名称
+}
+
+// GetRootVirtualService 域名在网关上的根 VirtualService，和网关在同一个命名空间，名称为 <gateway>-<host>-<hash>。
+// 替换 . 和 * 后不同的域名可能得到相同的名称，通过域名的摘要区分
+func GetRootVirtualService(gateway, host string) (string, string) {
+	namespace, name := ParseNamespacedName("istio-system", gateway)
+	readable := strings.ReplaceAll(host, "*", "wildcard")
+	readable = strings.ReplaceAll(readable, ".", "-")
+	readable = strings.ToLower(name + "-" + readable)
+	hash := GetRootHostHash(host)
+	if max := validation.DNS1123SubdomainMaxLength - len(hash) - 1; len(readable) > max {
+		readable = readable[:max]
+	}
+	return namespace, readable + "-" + hash
+}
+
+// GetRootHostHash 域名的摘要，作为根 VirtualService 名称的后缀和 RootVirtualServiceLabel 的值
+func GetRootHostHash(host string) string {
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(host))
+	return fmt.Sprintf("%08x", h.Sum32())
+}
+
+// GetDelegateRoutes 该 Plus 委托给根 VirtualService 的路由，包括 listener 的前缀和重定向的前缀。
+// 委托的 VirtualService 中的路由必须在根路由的匹配范围内
+func (r *Plus) GetDelegateRoutes(gateway, host string) []PlusDelegateRoute {
+	routes := make([]PlusDelegateRoute, 0)
+	if !r.GetDeletionTimestamp().IsZero() {
+		return routes
+	}
+	for _, listener := range r.GetListeners() {
+		if !listener.Delegate || listener.GetDelegateGateway(r) != gateway || !containsString(listener.Hosts, host) {
+			continue
+		}
+		prefixes := listener.GetRoutePrefixes(r)
+		for _, redirect := range r.Spec.Gateway.Redirects {
+			prefixes = append(prefixes, redirect.From)
+		}
+		for _, prefix := range prefixes {
+			routes = append(routes, PlusDelegateRoute{
+				Prefix:    prefix,
+				Namespace: r.GetNamespace(),
+				Name:      listener.GetVirtualServiceName(r),
+			})
+		}
+	}
+	return routes
+}
+
+// GetDelegateHosts 需要委托的网关和域名，key 为网关，value 为域名
+func (r *Plus) GetDelegateHosts() map[string][]string {
+	hosts := make(map[string][]string)
+	for _, listener := range r.GetListeners() {
+		if !listener.Delegate {
+			continue
+		}
+		gateway := listener.GetDelegateGateway(r)
+		hosts[gateway] = append(hosts[gateway], listener.Hosts...)
+	}
+	return hosts
+}
+
+// GetDelegateGateway 根 VirtualService 使用的网关 <namespace>/<name>，省略命名空间时为 Plus 所在的命名空间
+func (r *PlusGatewayListener) GetDelegateGateway(plus *Plus) string {
+	return r.GetNamespacedGateways(plus)[0]
+}
+
+// SortDelegateRoutes 最长前缀优先，前缀相同时按照命名空间和名称排序，保证生成的结果稳定
+func SortDelegateRoutes(routes []PlusDelegateRoute) {
+	sort.SliceStable(routes, func(i, j int) bool {
+		if len(routes[i].Prefix) != len(routes[j].Prefix) {
+			return len(routes[i].Prefix) > len(routes[j].Prefix)
+		}
+		if routes[i].Prefix != routes[j].Prefix {
+			return routes[i].Prefix < routes[j].Prefix
+		}
+		if routes[i].Namespace != routes[j].Namespace {
+			return routes[i].Namespace < routes[j].Namespace
+		}
+		return routes[i].Name < routes[j].Name
+	})
+}
@@ -0,0 +1,93 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"strings"
+	"testing"
+)
+
+func TestRootVirtualService(t *testing.T) {
+	namespace, name := GetRootVirtualService("istio-system/gateway", "api.a.com")
+	require.Equal(t, "istio-system", namespace)
+	require.Equal(t, "gateway-api-a-com-"+GetRootHostHash("api.a.com"), name)
+
+	namespace, name = GetRootVirtualService("internal/gateway", "*.a.com")
+	require.Equal(t, "internal", namespace)
+	require.Equal(t, "gateway-wildcard-a-com-"+GetRootHostHash("*.a.com"), name)
+
+	// 替换后相同的域名使用不同的名称
+	_, a := GetRootVirtualService("istio-system/gateway", "a-b.example.com")
+	_, b := GetRootVirtualService("istio-system/gateway", "a.b-example.com")
+	require.NotEqual(t, a, b)
+	_, a = GetRootVirtualService("istio-system/gateway", "*.x.com")
+	_, b = GetRootVirtualService("istio-system/gateway", "wildcard.x.com")
+	require.NotEqual(t, a, b)
+
+	// 名称不超过长度限制，摘要可以作为 label 的值
+	_, name = GetRootVirtualService("istio-system/gateway", strings.Repeat("a", 250)+".com")
+	require.Empty(t, validation.IsDNS1123Subdomain(name))
+	require.Empty(t, validation.IsValidLabelValue(GetRootHostHash("*.a.com")))
+}
+
+func TestDelegateRoutes(t *testing.T) {
+	svcA := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "default"},
+		Spec: PlusSpec{Gateway: &PlusGateway{
+			Hosts:     []string{"api.a.com"},
+			Delegate:  true,
+			Redirects: []*PlusGatewayRedirect{{From: "/old", To: "/svc-a/new"}},
+			Listeners: []*PlusGatewayListener{
+				{Name: "internal", Hosts: []string{"api.internal"}, Gateways: []string{"internal-gateway"}, Delegate: true},
+				{Name: "legacy", Hosts: []string{"api.a.com"}},
+			},
+		}},
+	}
+	require.Equal(t, map[string][]string{
+		"istio-system/gateway":     {"api.a.com"},
+		"default/internal-gateway": {"api.internal"},
+	}, svcA.GetDelegateHosts())
+
+	routes := svcA.GetDelegateRoutes(DefaultGateway, "api.a.com")
+	require.Equal(t, []PlusDelegateRoute{
+		{Prefix: "/svc-a", Namespace: "default", Name: "svc-a-gateway"},
+		{Prefix: "/old", Namespace: "default", Name: "svc-a-gateway"},
+	}, routes)
+	require.Empty(t, svcA.GetDelegateRoutes(DefaultGateway, "api.internal"))
+
+	emptyPrefix := ""
+	svcB := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-b", Namespace: "other"},
+		Spec: PlusSpec{Gateway: &PlusGateway{
+			Hosts:      []string{"api.a.com"},
+			PathPrefix: &emptyPrefix,
+			Delegate:   true,
+		}},
+	}
+	svcC := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-c", Namespace: "default"},
+		Spec: PlusSpec{Gateway: &PlusGateway{
+			Hosts:    []string{"api.a.com"},
+			Delegate: true,
+		}},
+	}
+	svcCSub := svcC.DeepCopy()
+	svcCSub.Name = "svc-c-sub"
+
+	routes = append(routes, svcB.GetDelegateRoutes(DefaultGateway, "api.a.com")...)
+	routes = append(routes, svcCSub.GetDelegateRoutes(DefaultGateway, "api.a.com")...)
+	routes = append(routes, svcC.GetDelegateRoutes(DefaultGateway, "api.a.com")...)
+	SortDelegateRoutes(routes)
+	require.Equal(t, []PlusDelegateRoute{
+		{Prefix: "/svc-c-sub", Namespace: "default", Name: "svc-c-sub-gateway"},
+		{Prefix: "/svc-a", Namespace: "default", Name: "svc-a-gateway"},
+		{Prefix: "/svc-c", Namespace: "default", Name: "svc-c-gateway"},
+		{Prefix: "/old", Namespace: "default", Name: "svc-a-gateway"},
+		{Prefix: "", Namespace: "other", Name: "svc-b-gateway"},
+	}, routes)
+
+	now := metav1.Now()
+	svcC.DeletionTimestamp = &now
+	require.Empty(t, svcC.GetDelegateRoutes(DefaultGateway, "api.a.com"))
+}
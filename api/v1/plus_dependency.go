@@ -0,0 +1,210 @@
+package v1
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// DependsOnField Plus 依赖的 Plus 的字段索引，值为 <namespace>/<name>，用于依赖就绪后通知依赖方
+const DependsOnField = "spec.dependsOn.plus"
+
+const (
+	// ConditionReady 所有版本都有可用的副本
+	ConditionReady = "Ready"
+	// ConditionDependenciesReady 依赖的 Plus 都已经 Ready
+	ConditionDependenciesReady = "DependenciesReady"
+	// DependenciesReadinessGate Pod 的 readinessGate，依赖就绪前 Pod 不会 Ready
+	DependenciesReadinessGate = "apps.clusterplus.io/dependencies-ready"
+	// DependenciesReadinessGateLabel 带有 readinessGate 的 Pod 的 label，controller 只缓存这些 Pod
+	DependenciesReadinessGateLabel = "apps.clusterplus.io/dependencies-ready-gate"
+)
+
+// PlusDependency 依赖的服务，plus 和 host 只能设置一个
+type PlusDependency struct {
+	Plus      string `json:"plus,omitempty"`      //<namespace>/<name> 或 <name>，默认为当前命名空间
+	Host      string `json:"host,omitempty"`      //<namespace>/<host> 或 <host>，和 Sidecar 的 hosts 格式一致，没有命名空间时为 */<host>
+	WaitReady bool   `json:"waitReady,omitempty"` //依赖的 Plus 第一次 Ready 之前 Pod 不会 Ready，只支持 plus，不能和依赖互相等待
+}
+
+// GetSidecarHost Sidecar 中允许访问的 host
+func (r *PlusDependency) GetSidecarHost(namespace string) string {
+	if r.Plus != "" {
+		ns, name := ParseNamespacedName(namespace, r.Plus)
+		return fmt.Sprintf("%s/%s.%s.svc.cluster.local", ns, name, ns)
+	}
+	if strings.Contains(r.Host, "/") {
+		return r.Host
+	}
+	return "*/" + r.Host
+}
+
+// GetSidecarHosts sidecar 只下发 istio-system、依赖的服务、全局限流服务和声明的外部依赖，减少 envoy 的内存。
+// 没有声明 dependsOn 时网格内的服务都可以访问，都没有声明时返回 nil，不限制 sidecar
+func (r *Plus) GetSidecarHosts() []string {
+	if len(r.Spec.Egress) == 0 && len(r.Spec.DependsOn) == 0 {
+		return nil
+	}
+	hosts := []string{"istio-system/*"}
+	if len(r.Spec.DependsOn) == 0 {
+		hosts = append(hosts, "*/*.svc.cluster.local")
+	}
+	for _, dependency := range r.Spec.DependsOn {
+		hosts = append(hosts, dependency.GetSidecarHost(r.GetNamespace()))
+	}
+	// 控制器生成的 EnvoyFilter 引用的网格内服务
+	if r.Spec.Policy != nil && r.Spec.Policy.RateLimit != nil && r.Spec.Policy.RateLimit.Global != nil {
+		hosts = append(hosts, r.Spec.Policy.RateLimit.Global.GetSidecarHost())
+	}
+	// ServiceEntry 只导出到当前命名空间
+	for _, egress := range r.Spec.Egress {
+		hosts = append(hosts, fmt.Sprintf("./%s", egress.Host))
+	}
+	return hosts
+}
+
+// GetDependencyPluses 依赖的 Plus，值为 <namespace>/<name>
+func (r *Plus) GetDependencyPluses(waitReady bool) []string {
+	pluses := make([]string, 0, len(r.Spec.DependsOn))
+	for _, dependency := range r.Spec.DependsOn {
+		if dependency.Plus == "" || (waitReady && !dependency.WaitReady) {
+			continue
+		}
+		ns, name := ParseNamespacedName(r.GetNamespace(), dependency.Plus)
+		pluses = append(pluses, fmt.Sprintf("%s/%s", ns, name))
+	}
+	return pluses
+}
+
+// IsWaitReady Pod 是否需要等待依赖就绪
+func (r *Plus) IsWaitReady() bool {
+	return len(r.GetDependencyPluses(true)) > 0
+}
+
+// IsAvailable 所有版本的可用副本都不少于最小副本数
+func (r *Plus) IsAvailable() bool {
+	if !r.Status.Success || len(r.Spec.Apps) == 0 {
+		return false
+	}
+	for _, app := range r.Spec.Apps {
+		// minReplicas 为 0 的版本可以没有副本，不影响 Ready
+		if r.Status.AvailableReplicas[app.Version] < app.MinReplicas {
+			return false
+		}
+	}
+	return true
+}
+
+// IsReady 由 controller 设置的 Ready 状态
+func (r *Plus) IsReady() bool {
+	return meta.IsStatusConditionTrue(r.Status.Conditions, ConditionReady)
+}
+
+// ListNotReadyDependencies 没有 Ready 的依赖，不存在的 Plus 也算作没有 Ready
+func (r *Plus) ListNotReadyDependencies(ctx context.Context, c client.Client) ([]string, error) {
+	notReady := make([]string, 0)
+	for _, key := range r.GetDependencyPluses(true) {
+		ns, name := ParseNamespacedName(r.GetNamespace(), key)
+		found := &Plus{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, found); err != nil {
+			if apierrors.IsNotFound(err) {
+				notReady = append(notReady, key)
+				continue
+			}
+			return nil, err
+		}
+		if !found.IsReady() {
+			notReady = append(notReady, key)
+		}
+	}
+	return notReady, nil
+}
+
+// ListDependencyCycles waitReady 的依赖同时 waitReady 等待当前 Plus 时，双方的 Pod 都不会 Ready
+func (r *Plus) ListDependencyCycles(ctx context.Context, c client.Reader) ([]string, error) {
+	self := fmt.Sprintf("%s/%s", r.GetNamespace(), r.GetName())
+	cycles := make([]string, 0)
+	for _, key := range r.GetDependencyPluses(true) {
+		ns, name := ParseNamespacedName(r.GetNamespace(), key)
+		found := &Plus{}
+		if err := c.Get(ctx, client.ObjectKey{Namespace: ns, Name: name}, found); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return nil, err
+		}
+		for _, dependency := range found.GetDependencyPluses(true) {
+			if dependency == self {
+				cycles = append(cycles, key)
+				break
+			}
+		}
+	}
+	return cycles, nil
+}
+
+// IndexDependsOn DependsOnField 的索引函数
+func IndexDependsOn(obj client.Object) []string {
+	plus, ok := obj.(*Plus)
+	if !ok {
+		return nil
+	}
+	return plus.GetDependencyPluses(true)
+}
+
+// SetupDependsOnIndex 注册 DependsOnField 索引
+func SetupDependsOnIndex(mgr ctrl.Manager) error {
+	return mgr.GetFieldIndexer().IndexField(context.Background(), &Plus{}, DependsOnField, IndexDependsOn)
+}
+
+// validateDependsOn 不能依赖自己，和其他 Plus 之间的循环等待由 webhook 通过 ListDependencyCycles 校验
+func (r *Plus) validateDependsOn(fldPath *field.Path) error {
+	fldPath = fldPath.Child("dependsOn")
+	for i, dependency := range r.Spec.DependsOn {
+		if err := dependency.Validate(fldPath.Index(i)); err != nil {
+			return err
+		}
+		if dependency.Plus == "" {
+			continue
+		}
+		ns, name := ParseNamespacedName(r.GetNamespace(), dependency.Plus)
+		if ns == r.GetNamespace() && name == r.GetName() {
+			err := field.Invalid(fldPath.Index(i).Child("plus"), dependency.Plus, "can't depend on itself")
+			return apierrors.NewInvalid(PlusKind, "plus", field.ErrorList{err})
+		}
+	}
+	return nil
+}
+
+func (r *PlusDependency) Validate(fldPath *field.Path) error {
+	if (r.Plus == "") == (r.Host == "") {
+		err := field.Invalid(fldPath, r, "one of plus or host must be set")
+		return apierrors.NewInvalid(PlusKind, "dependsOn", field.ErrorList{err})
+	}
+
+	if r.Plus != "" {
+		return validateNamespacedName(fldPath.Child("plus"), "plus", r.Plus)
+	}
+
+	if r.WaitReady {
+		err := field.Invalid(fldPath.Child("waitReady"), r.WaitReady, "waitReady is only supported by plus")
+		return apierrors.NewInvalid(PlusKind, "waitReady", field.ErrorList{err})
+	}
+
+	_, host := ParseNamespacedName("*", r.Host)
+	if strings.HasPrefix(host, "*.") {
+		host = strings.TrimPrefix(host, "*.")
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("host"), r.Host, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "host", field.ErrorList{err})
+	}
+	return nil
+}
@@ -0,0 +1,117 @@
+package v1
+
+import (
+	"context"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"testing"
+)
+
+func TestValidDependsOn(t *testing.T) {
+	plus := func(dependencies ...*PlusDependency) Plus {
+		return Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns"}, Spec: PlusSpec{
+			Apps:      []*PlusApp{{Version: "blue", MinReplicas: 1, MaxReplicas: 1, Port: 8080, Protocol: ProtocolHTTP}},
+			DependsOn: dependencies,
+		}}
+	}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: plus(&PlusDependency{Plus: "svc-b", WaitReady: true}, &PlusDependency{Plus: "other/svc-c"}), isErr: false},
+		{r: plus(&PlusDependency{Host: "redis.cache.svc.cluster.local"}, &PlusDependency{Host: "cache/*.cache.svc.cluster.local"}), isErr: false},
+		{r: plus(&PlusDependency{}), isErr: true},
+		{r: plus(&PlusDependency{Plus: "svc-b", Host: "redis.cache.svc.cluster.local"}), isErr: true},
+		{r: plus(&PlusDependency{Plus: "svc-a"}), isErr: true},
+		{r: plus(&PlusDependency{Plus: "ns/svc-a"}), isErr: true},
+		{r: plus(&PlusDependency{Plus: "a/b/c"}), isErr: true},
+		{r: plus(&PlusDependency{Host: "redis.cache.svc.cluster.local", WaitReady: true}), isErr: true},
+		{r: plus(&PlusDependency{Host: "Redis_Cache"}), isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate()
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestDependencies(t *testing.T) {
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns"}}
+	require.Nil(t, plus.GetSidecarHosts())
+	require.False(t, plus.IsWaitReady())
+	require.NotContains(t, plus.GenerateAppTemplateLabels(&PlusApp{Version: "blue"}), DependenciesReadinessGateLabel)
+
+	plus.Spec.DependsOn = []*PlusDependency{
+		{Plus: "svc-b", WaitReady: true},
+		{Plus: "other/svc-c"},
+		{Host: "redis.cache.svc.cluster.local"},
+		{Host: "cache/memcached.cache.svc.cluster.local"},
+	}
+	plus.Spec.Egress = []*PlusEgress{{Name: "payment", Host: "api.payment.example.com"}}
+	require.Equal(t, []string{
+		"istio-system/*",
+		"ns/svc-b.ns.svc.cluster.local",
+		"other/svc-c.other.svc.cluster.local",
+		"*/redis.cache.svc.cluster.local",
+		"cache/memcached.cache.svc.cluster.local",
+		"./api.payment.example.com",
+	}, plus.GetSidecarHosts())
+	require.True(t, plus.IsWaitReady())
+	require.Equal(t, "true", plus.GenerateAppTemplateLabels(&PlusApp{Version: "blue"})[DependenciesReadinessGateLabel])
+	require.Equal(t, []string{"ns/svc-b"}, IndexDependsOn(plus))
+	require.Equal(t, []string{"ns/svc-b", "other/svc-c"}, plus.GetDependencyPluses(false))
+
+	// 限制出口时仍然需要下发全局限流服务
+	plus.Spec.Policy = &PlusPolicy{RateLimit: &PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{Service: "ratelimit.ratelimit.svc.cluster.local"}}}
+	require.Contains(t, plus.GetSidecarHosts(), "*/ratelimit.ratelimit.svc.cluster.local")
+	plus.Spec.DependsOn = nil
+	require.Contains(t, plus.GetSidecarHosts(), "*/ratelimit.ratelimit.svc.cluster.local")
+}
+
+func TestIsAvailable(t *testing.T) {
+	plus := &Plus{Spec: PlusSpec{Apps: []*PlusApp{{Version: "blue", MinReplicas: 2}, {Version: "green", MinReplicas: 0}}}}
+	require.False(t, plus.IsAvailable())
+
+	plus.Status.Success = true
+	plus.Status.AvailableReplicas = map[string]int32{"blue": 1}
+	require.False(t, plus.IsAvailable())
+
+	// minReplicas 为 0 的版本没有副本时也是 Ready
+	plus.Status.AvailableReplicas["blue"] = 2
+	require.True(t, plus.IsAvailable())
+
+	plus.Status.AvailableReplicas["green"] = 1
+	require.True(t, plus.IsAvailable())
+
+	plus.Status.AvailableReplicas["blue"] = 1
+	require.False(t, plus.IsAvailable())
+}
+
+func TestListDependencyCycles(t *testing.T) {
+	svcA := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns"},
+		Spec: PlusSpec{DependsOn: []*PlusDependency{{Plus: "svc-b", WaitReady: true}, {Plus: "other/svc-c", WaitReady: true}}}}
+	svcB := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-b", Namespace: "ns"},
+		Spec: PlusSpec{DependsOn: []*PlusDependency{{Plus: "svc-a", WaitReady: true}}}}
+	svcC := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-c", Namespace: "other"},
+		Spec: PlusSpec{DependsOn: []*PlusDependency{{Plus: "ns/svc-a"}}}}
+
+	scheme := runtime.NewScheme()
+	require.Nil(t, AddToScheme(scheme))
+	c := fake.NewClientBuilder().WithScheme(scheme).WithObjects(svcB, svcC).Build()
+
+	// svc-c 只声明了依赖，没有等待 svc-a
+	cycles, err := svcA.ListDependencyCycles(context.TODO(), c)
+	require.Nil(t, err)
+	require.Equal(t, []string{"ns/svc-b"}, cycles)
+
+	// 依赖的 Plus 不存在时不算循环
+	svcA.Spec.DependsOn = []*PlusDependency{{Plus: "svc-d", WaitReady: true}}
+	cycles, err = svcA.ListDependencyCycles(context.TODO(), c)
+	require.Nil(t, err)
+	require.Empty(t, cycles)
+}
@@ -0,0 +1,202 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// ServiceEntry 的域名解析方式
+const (
+	EgressResolutionDNS    = "DNS"
+	EgressResolutionStatic = "STATIC"
+	EgressResolutionNone   = "NONE"
+)
+
+var egressResolutions = []string{EgressResolutionDNS, EgressResolutionStatic, EgressResolutionNone}
+
+// TLS 发起的模式，MUTUAL 需要 credentialName 指定客户端证书
+const (
+	EgressTlsModeSimple = "SIMPLE"
+	EgressTlsModeMutual = "MUTUAL"
+)
+
+var egressTlsModes = []string{EgressTlsModeSimple, EgressTlsModeMutual}
+
+// PlusEgress 网格外的依赖，生成 ServiceEntry，并且限制 sidecar 只能访问声明的依赖
+type PlusEgress struct {
+	Name       string           `json:"name"`                 //生成的资源名称为 <plus>-egress-<name>
+	Host       string           `json:"host"`                 //外部域名，resolution 为 NONE 时可以使用 *.example.com
+	Ports      []PlusEgressPort `json:"ports"`                //外部服务的端口
+	Resolution string           `json:"resolution,omitempty"` //DNS(默认), STATIC, NONE
+	Endpoints  []string         `json:"endpoints,omitempty"`  //resolution 为 STATIC 时的地址
+	Tls        *PlusEgressTls   `json:"tls,omitempty"`        //由 sidecar 发起 TLS，程序使用明文访问
+}
+
+type PlusEgressPort struct {
+	Number     int32  `json:"number"`
+	Protocol   string `json:"protocol"`             //和 app 的 protocol 相同，http, https, grpc, tcp, tls 等
+	TargetPort int32  `json:"targetPort,omitempty"` //实际连接的端口，发起 TLS 时通常为 443
+}
+
+// PlusEgressTls 生成 DestinationRule，sidecar 访问外部服务时发起 TLS
+type PlusEgressTls struct {
+	Mode           string `json:"mode,omitempty"`           //SIMPLE(默认) 或 MUTUAL
+	Sni            string `json:"sni,omitempty"`            //默认为 host
+	CredentialName string `json:"credentialName,omitempty"` //客户端证书的 secret，MUTUAL 时必填
+}
+
+// GetResourceName ServiceEntry 和 DestinationRule 的名称
+func (r *PlusEgress) GetResourceName(plus *Plus) string {
+	return fmt.Sprintf("%s-egress-%s", plus.GetName(), r.Name)
+}
+
+func (r *PlusEgress) GetResolution() istioapiv1.ServiceEntry_Resolution {
+	switch r.Resolution {
+	case EgressResolutionStatic:
+		return istioapiv1.ServiceEntry_STATIC
+	case EgressResolutionNone:
+		return istioapiv1.ServiceEntry_NONE
+	default:
+		return istioapiv1.ServiceEntry_DNS
+	}
+}
+
+func (r *PlusEgressPort) GetName() string {
+	return fmt.Sprintf("%s-%d", strings.ToLower(r.GetIstioProtocol()), r.Number)
+}
+
+func (r *PlusEgressPort) GetIstioProtocol() string {
+	return istioProtocol(r.Protocol)
+}
+
+// GetClientTLSSettings DestinationRule 中发起 TLS 的配置
+func (r *PlusEgressTls) GetClientTLSSettings(host string) *istioapiv1.ClientTLSSettings {
+	settings := &istioapiv1.ClientTLSSettings{
+		Mode:           istioapiv1.ClientTLSSettings_SIMPLE,
+		CredentialName: r.CredentialName,
+	}
+	// 通配符域名使用请求中的域名
+	if !strings.HasPrefix(host, "*.") {
+		settings.Sni = host
+	}
+	if r.Mode == EgressTlsModeMutual {
+		settings.Mode = istioapiv1.ClientTLSSettings_MUTUAL
+	}
+	if r.Sni != "" {
+		settings.Sni = r.Sni
+	}
+	return settings
+}
+
+// validateEgress 名称用于生成资源名称，不能重复
+func (r *Plus) validateEgress(fldPath *field.Path) error {
+	names := make(map[string]bool, len(r.Spec.Egress))
+	for i, egress := range r.Spec.Egress {
+		if names[egress.Name] {
+			err := field.Duplicate(fldPath.Child("egress").Index(i).Child("name"), egress.Name)
+			return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+		}
+		names[egress.Name] = true
+		if err := egress.Validate(fldPath.Child("egress").Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PlusEgress) Validate(fldPath *field.Path) error {
+	if errs := validation.IsDNS1123Label(r.Name); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("name"), r.Name, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+	}
+
+	if r.Resolution != "" && !containsString(egressResolutions, r.Resolution) {
+		err := field.NotSupported(fldPath.Child("resolution"), r.Resolution, egressResolutions)
+		return apierrors.NewInvalid(PlusKind, "resolution", field.ErrorList{err})
+	}
+
+	// 通配符域名无法解析，只能透传
+	host := r.Host
+	if strings.HasPrefix(host, "*.") && r.Resolution == EgressResolutionNone {
+		host = strings.TrimPrefix(host, "*.")
+	}
+	if errs := validation.IsDNS1123Subdomain(host); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("host"), r.Host, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "host", field.ErrorList{err})
+	}
+
+	if len(r.Ports) == 0 {
+		err := field.Required(fldPath.Child("ports"), "ports can't be empty")
+		return apierrors.NewInvalid(PlusKind, "ports", field.ErrorList{err})
+	}
+	for i, port := range r.Ports {
+		if err := port.Validate(fldPath.Child("ports").Index(i)); err != nil {
+			return err
+		}
+	}
+
+	if r.Resolution == EgressResolutionStatic && len(r.Endpoints) == 0 {
+		err := field.Required(fldPath.Child("endpoints"), "endpoints is required when resolution is STATIC")
+		return apierrors.NewInvalid(PlusKind, "endpoints", field.ErrorList{err})
+	}
+	if r.Resolution != EgressResolutionStatic && len(r.Endpoints) > 0 {
+		err := field.Invalid(fldPath.Child("endpoints"), r.Endpoints, "endpoints is only supported when resolution is STATIC")
+		return apierrors.NewInvalid(PlusKind, "endpoints", field.ErrorList{err})
+	}
+	for i, endpoint := range r.Endpoints {
+		if errs := validation.IsValidIP(endpoint); len(errs) != 0 {
+			err := field.Invalid(fldPath.Child("endpoints").Index(i), endpoint, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "endpoints", field.ErrorList{err})
+		}
+	}
+
+	if e := r.Tls; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+		// 发起 TLS 时程序使用明文访问，端口不能已经是 TLS 流量
+		for i, port := range r.Ports {
+			if port.Protocol == ProtocolHTTPS || port.Protocol == ProtocolTLS {
+				err := field.Invalid(fldPath.Child("ports").Index(i).Child("protocol"), port.Protocol, "tls origination requires plaintext protocol")
+				return apierrors.NewInvalid(PlusKind, "protocol", field.ErrorList{err})
+			}
+		}
+	}
+	return nil
+}
+
+func (r *PlusEgressPort) Validate(fldPath *field.Path) error {
+	if errs := validation.IsValidPortNum(int(r.Number)); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("number"), r.Number, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "number", field.ErrorList{err})
+	}
+	if r.TargetPort != 0 {
+		if errs := validation.IsValidPortNum(int(r.TargetPort)); len(errs) != 0 {
+			err := field.Invalid(fldPath.Child("targetPort"), r.TargetPort, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "targetPort", field.ErrorList{err})
+		}
+	}
+	if !containsString(supportedProtocols, r.Protocol) {
+		err := field.NotSupported(fldPath.Child("protocol"), r.Protocol, supportedProtocols)
+		return apierrors.NewInvalid(PlusKind, "protocol", field.ErrorList{err})
+	}
+	return nil
+}
+
+func (r *PlusEgressTls) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("tls")
+	if r.Mode != "" && !containsString(egressTlsModes, r.Mode) {
+		err := field.NotSupported(fldPath.Child("mode"), r.Mode, egressTlsModes)
+		return apierrors.NewInvalid(PlusKind, "mode", field.ErrorList{err})
+	}
+	if r.Mode == EgressTlsModeMutual && r.CredentialName == "" {
+		err := field.Required(fldPath.Child("credentialName"), "credentialName is required when mode is MUTUAL")
+		return apierrors.NewInvalid(PlusKind, "credentialName", field.ErrorList{err})
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidEgress(t *testing.T) {
+	ports := []PlusEgressPort{{Number: 80, Protocol: ProtocolHTTP, TargetPort: 443}}
+	tests := []struct {
+		r     PlusEgress
+		isErr bool
+	}{
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: ports, Tls: &PlusEgressTls{}}, isErr: false},
+		{r: PlusEgress{Name: "mysql", Host: "mysql.example.com", Resolution: EgressResolutionStatic, Endpoints: []string{"10.0.0.10"},
+			Ports: []PlusEgressPort{{Number: 3306, Protocol: ProtocolTCP}}}, isErr: false},
+		{r: PlusEgress{Name: "wildcard", Host: "*.example.com", Resolution: EgressResolutionNone, Ports: ports}, isErr: false},
+		{r: PlusEgress{Name: "wildcard", Host: "*.example.com", Ports: ports}, isErr: true},
+		{r: PlusEgress{Name: "Payment", Host: "api.payment.example.com", Ports: ports}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com"}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: []PlusEgressPort{{Number: 0, Protocol: ProtocolHTTP}}}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: []PlusEgressPort{{Number: 80, Protocol: "udp"}}}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: ports, Resolution: "DNS_ROUND_ROBIN"}, isErr: true},
+		{r: PlusEgress{Name: "mysql", Host: "mysql.example.com", Resolution: EgressResolutionStatic, Ports: ports}, isErr: true},
+		{r: PlusEgress{Name: "mysql", Host: "mysql.example.com", Endpoints: []string{"10.0.0.10"}, Ports: ports}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: ports, Tls: &PlusEgressTls{Mode: EgressTlsModeMutual}}, isErr: true},
+		{r: PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: []PlusEgressPort{{Number: 443, Protocol: ProtocolHTTPS}}, Tls: &PlusEgressTls{}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+
+	egress := &PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: ports}
+	plus := &Plus{Spec: PlusSpec{Egress: []*PlusEgress{egress, egress}}}
+	require.NotNil(t, plus.validateEgress(field.NewPath("spec")))
+}
+
+func TestEgressSettings(t *testing.T) {
+	egress := &PlusEgress{Name: "payment", Host: "api.payment.example.com", Ports: []PlusEgressPort{{Number: 80, Protocol: ProtocolNone}}}
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a"}}
+	require.Nil(t, plus.GetSidecarHosts())
+
+	plus.Spec.Egress = []*PlusEgress{egress}
+	require.Equal(t, "svc-a-egress-payment", egress.GetResourceName(plus))
+	require.Equal(t, istioapiv1.ServiceEntry_DNS, egress.GetResolution())
+	require.Equal(t, "tcp-80", egress.Ports[0].GetName())
+	require.Equal(t, []string{"istio-system/*", "*/*.svc.cluster.local", "./api.payment.example.com"}, plus.GetSidecarHosts())
+
+	settings := (&PlusEgressTls{}).GetClientTLSSettings(egress.Host)
+	require.Equal(t, istioapiv1.ClientTLSSettings_SIMPLE, settings.Mode)
+	require.Equal(t, egress.Host, settings.Sni)
+	settings = (&PlusEgressTls{Mode: EgressTlsModeMutual, CredentialName: "client-cert"}).GetClientTLSSettings("*.example.com")
+	require.Equal(t, istioapiv1.ClientTLSSettings_MUTUAL, settings.Mode)
+	require.Equal(t, "", settings.Sni)
+}
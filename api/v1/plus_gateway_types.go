@@ -2,7 +2,11 @@ package v1
 
 import (
 	"fmt"
+	"strings"
+
+	istioapiv1 "istio.io/api/networking/v1alpha3"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -12,27 +16,140 @@ type PlusGateway struct {
 	Weights    map[string]int32             `json:"weights,omitempty"`
 	Route      map[string]*PlusGatewayRoute `json:"route,omitempty"`
 	PathPrefix *string                      `json:"pathPrefix,omitempty"`
+	// Headers 网关所有路由的请求头/响应头操作
+	Headers *PlusHeaders `json:"headers,omitempty"`
+	// VersionResponseHeader 不为空时，在响应头中写入处理该请求的版本，如 x-plus-version
+	VersionResponseHeader string `json:"versionResponseHeader,omitempty"`
+	// Maintenance 维护模式，开启后直接返回配置的响应
+	Maintenance *PlusGatewayMaintenance `json:"maintenance,omitempty"`
+	// HttpsRedirect 将 http 请求重定向到 https
+	HttpsRedirect bool `json:"httpsRedirect,omitempty"`
+	// Redirects 重定向规则，用于迁移了前缀的接口
+	Redirects []*PlusGatewayRedirect `json:"redirects,omitempty"`
+	// Access 网关访问的黑白名单和 JWT 认证
+	Access *PlusGatewayAccess `json:"access,omitempty"`
+	// Listeners 额外的入口，例如内网域名使用不同的网关和前缀
+	Listeners []*PlusGatewayListener `json:"listeners,omitempty"`
+	// Delegate 使用 istio 委托，由每个域名的根 VirtualService 按照最长前缀优先转发到 <name>-gateway
+	Delegate bool `json:"delegate,omitempty"`
+	// Grpc 所有版本都是 grpc 服务时按照服务名和方法路由，不使用 pathPrefix；不配置时按照 pathPrefix 路由
+	Grpc *PlusGatewayGrpc `json:"grpc,omitempty"`
+}
+
+type PlusGatewayMaintenance struct {
+	Enabled     bool   `json:"enabled,omitempty"`
+	Status      uint32 `json:"status,omitempty"`      //响应状态码，默认 503
+	Body        string `json:"body,omitempty"`        //响应内容
+	ContentType string `json:"contentType,omitempty"` //响应内容类型，如 application/json
+	// AllowHeadersMatch 匹配请求头的请求可以继续访问，每一项内的请求头需要全部匹配
+	AllowHeadersMatch []map[string]string `json:"allowHeadersMatch,omitempty"`
+	// AllowSourceIPs 来源 IP 在列表中的请求可以继续访问，根据网关设置的 x-envoy-external-address 匹配
+	AllowSourceIPs []string `json:"allowSourceIPs,omitempty"`
+	// AllowVersion 允许访问的请求转发到的版本，为空时按照网关配置的流量比例转发
+	AllowVersion string `json:"allowVersion,omitempty"`
+}
+
+// PlusGatewayRedirect 将 from 前缀的请求重定向到 to，to 会替换请求的整个路径
+type PlusGatewayRedirect struct {
+	From      string `json:"from,omitempty"`
+	To        string `json:"to,omitempty"`
+	Authority string `json:"authority,omitempty"` //重定向到的域名，为空时不变
+	Code      uint32 `json:"code,omitempty"`      //重定向状态码，默认 301
 }
 
 type PlusGatewayRoute struct {
 	HeadersMatch []map[string]string `json:"headersMatch,omitempty"`
+	// Headers 该版本自定义路由的请求头/响应头操作，覆盖网关级别的同名配置
+	Headers *PlusHeaders `json:"headers,omitempty"`
+	// Cors 该版本自定义路由的跨域配置，整体覆盖网关级别的配置
+	Cors *PlusGatewayCors `json:"cors,omitempty"`
+}
+
+// PlusHeaders 描述请求头和响应头的操作
+type PlusHeaders struct {
+	Request  *PlusHeaderOperations `json:"request,omitempty"`
+	Response *PlusHeaderOperations `json:"response,omitempty"`
 }
 
-type PlusGatewayCors struct {
-	AllowOrigins  []string `json:"allowOrigins,omitempty"`
-	AllowMethods  []string `json:"allowMethods,omitempty"`
-	AllowHeaders  []string `json:"allowHeaders,omitempty"`
-	ExposeHeaders []string `json:"exposeHeaders,omitempty"`
+type PlusHeaderOperations struct {
+	Set    map[string]string `json:"set,omitempty"`
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
 }
 
 func (r *PlusGateway) Validate(fldPath *field.Path) error {
 	fldPath = fldPath.Child("gateway")
 
-	if r.Hosts == nil || len(r.Hosts) == 0 {
-		err := field.Invalid(fldPath.Child("hosts"), r.Hosts, "hosts can't be empty")
+	if len(r.Hosts) == 0 && len(r.Listeners) == 0 {
+		err := field.Invalid(fldPath.Child("hosts"), r.Hosts, "hosts and listeners can't both be empty")
 		return apierrors.NewInvalid(PlusKind, "hosts", field.ErrorList{err})
 	}
 
+	names := make(map[string]bool, len(r.Listeners))
+	for i, e := range r.Listeners {
+		if err := e.Validate(fldPath.Child("listeners").Index(i)); err != nil {
+			return err
+		}
+		if names[e.Name] {
+			err := field.Duplicate(fldPath.Child("listeners").Index(i).Child("name"), e.Name)
+			return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+		}
+		names[e.Name] = true
+	}
+
+	if e := r.Headers; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if r.VersionResponseHeader != "" {
+		if errs := validation.IsHTTPHeaderName(r.VersionResponseHeader); len(errs) != 0 {
+			err := field.Invalid(fldPath.Child("versionResponseHeader"), r.VersionResponseHeader, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "versionResponseHeader", field.ErrorList{err})
+		}
+	}
+
+	if e := r.Maintenance; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Access; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Cors; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	for i, e := range r.Redirects {
+		if err := e.Validate(fldPath.Child("redirects").Index(i)); err != nil {
+			return err
+		}
+	}
+
+	for version, route := range r.Route {
+		if route == nil {
+			continue
+		}
+		if route.Headers != nil {
+			if err := route.Headers.Validate(fldPath.Child("route").Key(version)); err != nil {
+				return err
+			}
+		}
+		if route.Cors != nil {
+			if err := route.Cors.Validate(fldPath.Child("route").Key(version)); err != nil {
+				return err
+			}
+		}
+	}
+
 	if r.Weights == nil || len(r.Weights) == 0 {
 		return nil
 	}
@@ -49,3 +166,188 @@ func (r *PlusGateway) Validate(fldPath *field.Path) error {
 
 	return nil
 }
+
+// IsEnabled 是否开启了维护模式
+func (r *PlusGatewayMaintenance) IsEnabled() bool {
+	return r != nil && r.Enabled
+}
+
+func (r *PlusGatewayMaintenance) GetStatus() uint32 {
+	if r.Status == 0 {
+		return 503
+	}
+	return r.Status
+}
+
+func (r *PlusGatewayRedirect) GetCode() uint32 {
+	if r.Code == 0 {
+		return 301
+	}
+	return r.Code
+}
+
+// validateMaintenance allowVersion 需要是已有的版本，否则允许访问的请求会转发到所有版本
+func (r *Plus) validateMaintenance(fldPath *field.Path) error {
+	if r.Spec.Gateway == nil || r.Spec.Gateway.Maintenance == nil || r.Spec.Gateway.Maintenance.AllowVersion == "" {
+		return nil
+	}
+	versions := make([]string, 0, len(r.Spec.Apps))
+	for _, app := range r.Spec.Apps {
+		versions = append(versions, app.Version)
+	}
+	if allowVersion := r.Spec.Gateway.Maintenance.AllowVersion; !containsString(versions, allowVersion) {
+		err := field.NotSupported(fldPath.Child("gateway", "maintenance", "allowVersion"), allowVersion, versions)
+		return apierrors.NewInvalid(PlusKind, "allowVersion", field.ErrorList{err})
+	}
+	return nil
+}
+
+func (r *PlusGatewayMaintenance) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("maintenance")
+
+	if r.Status != 0 && (r.Status < 200 || r.Status > 599) {
+		err := field.Invalid(fldPath.Child("status"), r.Status, "status must between 200 and 599")
+		return apierrors.NewInvalid(PlusKind, "status", field.ErrorList{err})
+	}
+
+	for i, ip := range r.AllowSourceIPs {
+		if errs := validation.IsValidIP(ip); len(errs) != 0 {
+			err := field.Invalid(fldPath.Child("allowSourceIPs").Index(i), ip, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "allowSourceIPs", field.ErrorList{err})
+		}
+	}
+
+	return nil
+}
+
+func (r *PlusGatewayRedirect) Validate(fldPath *field.Path) error {
+	if !strings.HasPrefix(r.From, "/") {
+		err := field.Invalid(fldPath.Child("from"), r.From, "from must start with /")
+		return apierrors.NewInvalid(PlusKind, "from", field.ErrorList{err})
+	}
+
+	if r.To == "" && r.Authority == "" {
+		err := field.Invalid(fldPath.Child("to"), r.To, "one of to and authority must be set")
+		return apierrors.NewInvalid(PlusKind, "to", field.ErrorList{err})
+	}
+
+	if r.Code != 0 && r.Code != 301 && r.Code != 302 && r.Code != 303 && r.Code != 307 && r.Code != 308 {
+		err := field.NotSupported(fldPath.Child("code"), r.Code, []string{"301", "302", "303", "307", "308"})
+		return apierrors.NewInvalid(PlusKind, "code", field.ErrorList{err})
+	}
+
+	return nil
+}
+
+// MergeHeaders 合并两级请求头操作，override 中同名的 set/add 覆盖 base，remove 取并集
+func MergeHeaders(base, override *PlusHeaders) *PlusHeaders {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	return &PlusHeaders{
+		Request:  mergeHeaderOperations(base.Request, override.Request),
+		Response: mergeHeaderOperations(base.Response, override.Response),
+	}
+}
+
+func mergeHeaderOperations(base, override *PlusHeaderOperations) *PlusHeaderOperations {
+	if base == nil {
+		return override
+	}
+	if override == nil {
+		return base
+	}
+	merged := &PlusHeaderOperations{
+		Set: make(map[string]string, len(base.Set)+len(override.Set)),
+		Add: make(map[string]string, len(base.Add)+len(override.Add)),
+	}
+	for k, v := range base.Set {
+		merged.Set[k] = v
+	}
+	for k, v := range override.Set {
+		merged.Set[k] = v
+	}
+	for k, v := range base.Add {
+		merged.Add[k] = v
+	}
+	for k, v := range override.Add {
+		merged.Add[k] = v
+	}
+	merged.Remove = append(merged.Remove, base.Remove...)
+	for _, h := range override.Remove {
+		if !containsHeader(merged.Remove, h) {
+			merged.Remove = append(merged.Remove, h)
+		}
+	}
+	return merged
+}
+
+func containsHeader(headers []string, h string) bool {
+	for _, v := range headers {
+		if strings.EqualFold(v, h) {
+			return true
+		}
+	}
+	return false
+}
+
+// GetHeaders 转换为 istio 的 Headers
+func (r *PlusHeaders) GetHeaders() *istioapiv1.Headers {
+	if r == nil || (r.Request == nil && r.Response == nil) {
+		return nil
+	}
+	return &istioapiv1.Headers{
+		Request:  r.Request.GetHeaderOperations(),
+		Response: r.Response.GetHeaderOperations(),
+	}
+}
+
+func (r *PlusHeaderOperations) GetHeaderOperations() *istioapiv1.Headers_HeaderOperations {
+	if r == nil {
+		return nil
+	}
+	return &istioapiv1.Headers_HeaderOperations{
+		Set:    r.Set,
+		Add:    r.Add,
+		Remove: r.Remove,
+	}
+}
+
+func (r *PlusHeaders) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("headers")
+
+	if e := r.Request; e != nil {
+		if err := e.Validate(fldPath.Child("request")); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Response; e != nil {
+		if err := e.Validate(fldPath.Child("response")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PlusHeaderOperations) Validate(fldPath *field.Path) error {
+	names := make([]string, 0, len(r.Set)+len(r.Add)+len(r.Remove))
+	for k := range r.Set {
+		names = append(names, k)
+	}
+	for k := range r.Add {
+		names = append(names, k)
+	}
+	names = append(names, r.Remove...)
+
+	for _, name := range names {
+		if errs := validation.IsHTTPHeaderName(name); len(errs) != 0 {
+			err := field.Invalid(fldPath, name, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "headers", field.ErrorList{err})
+		}
+	}
+	return nil
+}
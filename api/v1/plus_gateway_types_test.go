@@ -0,0 +1,172 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestMergeHeaders(t *testing.T) {
+	base := &PlusHeaders{
+		Request: &PlusHeaderOperations{
+			Set:    map[string]string{"x-env": "fat", "x-team": "a"},
+			Remove: []string{"x-internal"},
+		},
+	}
+	override := &PlusHeaders{
+		Request: &PlusHeaderOperations{
+			Set:    map[string]string{"x-team": "b"},
+			Remove: []string{"X-Internal", "x-debug"},
+		},
+		Response: &PlusHeaderOperations{
+			Set: map[string]string{"x-plus-version": "blue"},
+		},
+	}
+
+	merged := MergeHeaders(base, override)
+	require.Equal(t, map[string]string{"x-env": "fat", "x-team": "b"}, merged.Request.Set)
+	require.Equal(t, []string{"x-internal", "x-debug"}, merged.Request.Remove)
+	require.Equal(t, "blue", merged.Response.Set["x-plus-version"])
+	require.Equal(t, "a", base.Request.Set["x-team"])
+
+	require.Nil(t, MergeHeaders(nil, nil).GetHeaders())
+	require.Equal(t, base, MergeHeaders(base, nil))
+}
+
+func TestValidHeaders(t *testing.T) {
+	tests := []struct {
+		r     PlusHeaders
+		isErr bool
+	}{
+		{r: PlusHeaders{Request: &PlusHeaderOperations{Set: map[string]string{"x-plus-version": "blue"}}}, isErr: false},
+		{r: PlusHeaders{Response: &PlusHeaderOperations{Remove: []string{"server"}}}, isErr: false},
+		{r: PlusHeaders{Request: &PlusHeaderOperations{Add: map[string]string{"x plus": "blue"}}}, isErr: true},
+		{r: PlusHeaders{Response: &PlusHeaderOperations{Remove: []string{""}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestValidGatewayMaintenance(t *testing.T) {
+	hosts := []string{"api-fat.tanjingmama.cn"}
+	tests := []struct {
+		r     PlusGateway
+		isErr bool
+	}{
+		{r: PlusGateway{Hosts: hosts, Maintenance: &PlusGatewayMaintenance{Enabled: true, AllowSourceIPs: []string{"10.0.0.1"}}}, isErr: false},
+		{r: PlusGateway{Hosts: hosts, Maintenance: &PlusGatewayMaintenance{Enabled: true, Status: 600}}, isErr: true},
+		{r: PlusGateway{Hosts: hosts, Maintenance: &PlusGatewayMaintenance{Enabled: true, AllowSourceIPs: []string{"10.0.0.0/8"}}}, isErr: true},
+		{r: PlusGateway{Hosts: hosts, Redirects: []*PlusGatewayRedirect{{From: "/old", To: "/new"}}}, isErr: false},
+		{r: PlusGateway{Hosts: hosts, Redirects: []*PlusGatewayRedirect{{From: "old", To: "/new"}}}, isErr: true},
+		{r: PlusGateway{Hosts: hosts, Redirects: []*PlusGatewayRedirect{{From: "/old"}}}, isErr: true},
+		{r: PlusGateway{Hosts: hosts, Redirects: []*PlusGatewayRedirect{{From: "/old", To: "/new", Code: 200}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestValidMaintenanceAllowVersion(t *testing.T) {
+	plus := func(allowVersion string) Plus {
+		return Plus{Spec: PlusSpec{
+			Gateway: &PlusGateway{Maintenance: &PlusGatewayMaintenance{Enabled: true, AllowVersion: allowVersion}},
+			Apps:    []*PlusApp{{Version: "blue"}, {Version: "green"}},
+		}}
+	}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: plus(""), isErr: false},
+		{r: plus("green"), isErr: false},
+		{r: plus("red"), isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.validateMaintenance(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestValidGatewayAccess(t *testing.T) {
+	tests := []struct {
+		r     PlusGatewayAccess
+		isErr bool
+	}{
+		{r: PlusGatewayAccess{AllowIPs: []string{"10.0.0.0/8", "192.168.1.1"}, DenyIPs: []string{"1.2.3.4"}}, isErr: false},
+		{r: PlusGatewayAccess{Jwt: &PlusGatewayJwt{Issuer: "https://auth", JwksUri: "https://auth/jwks"}}, isErr: false},
+		{r: PlusGatewayAccess{AllowIPs: []string{"10.0.0.0/33"}}, isErr: true},
+		{r: PlusGatewayAccess{DenyIPs: []string{"abc"}}, isErr: true},
+		{r: PlusGatewayAccess{Jwt: &PlusGatewayJwt{JwksUri: "https://auth/jwks"}}, isErr: true},
+		{r: PlusGatewayAccess{Jwt: &PlusGatewayJwt{Issuer: "https://auth", JwksUri: "https://auth/jwks", Jwks: "{}"}}, isErr: true},
+		{r: PlusGatewayAccess{Jwt: &PlusGatewayJwt{Issuer: "https://auth", Rules: []*PlusGatewayJwtRule{{Claims: map[string][]string{"role": nil}}}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestValidCors(t *testing.T) {
+	tests := []struct {
+		r     PlusGatewayCors
+		isErr bool
+	}{
+		{r: PlusGatewayCors{AllowOrigins: []string{"https://a.com"}, AllowOriginPrefixes: []string{"https://dev-"}, AllowOriginRegexes: []string{`https://.*\.a\.com`}, MaxAge: "1h"}, isErr: false},
+		{r: PlusGatewayCors{AllowOriginPrefixes: []string{""}}, isErr: true},
+		{r: PlusGatewayCors{AllowOriginRegexes: []string{"https://(a"}}, isErr: true},
+		{r: PlusGatewayCors{MaxAge: "1"}, isErr: true},
+		{r: PlusGatewayCors{MaxAge: "-1h"}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestCorsPolicy(t *testing.T) {
+	cors := &PlusGatewayCors{AllowOrigins: []string{"https://a.com"}, AllowOriginRegexes: []string{`https://.*\.a\.com`}}
+	policy := cors.GetCorsPolicy()
+	require.Equal(t, int64(24*60*60), policy.MaxAge.Seconds)
+	require.True(t, policy.AllowCredentials.Value)
+	require.Equal(t, DefaultCorsAllowMethods, policy.AllowMethods)
+	require.Equal(t, DefaultCorsAllowHeaders, policy.AllowHeaders)
+	require.Nil(t, cors.AllowMethods)
+	require.Equal(t, "https://a.com", policy.AllowOrigins[0].GetExact())
+	require.Equal(t, `https://.*\.a\.com`, policy.AllowOrigins[1].GetRegex())
+
+	cors.Default()
+	policy = cors.GetCorsPolicy()
+	require.Equal(t, int64(24*60*60), policy.MaxAge.Seconds)
+	require.True(t, policy.AllowCredentials.Value)
+	require.Equal(t, DefaultCorsAllowMethods, policy.AllowMethods)
+
+	allowCredentials := false
+	cors = &PlusGatewayCors{AllowCredentials: &allowCredentials, MaxAge: "10m"}
+	cors.Default()
+	policy = cors.GetCorsPolicy()
+	require.False(t, policy.AllowCredentials.Value)
+	require.Equal(t, int64(600), policy.MaxAge.Seconds)
+}
@@ -0,0 +1,148 @@
+package v1
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultGrpcRetryOn grpc 服务配置了重试但没有配置 retryOn 时使用的重试条件
+const DefaultGrpcRetryOn = "unavailable,resource-exhausted"
+
+var (
+	// GrpcWebAllowHeaders 浏览器通过 grpc-web 调用时需要的请求头
+	GrpcWebAllowHeaders = []string{"content-type", "x-grpc-web", "x-user-agent", "grpc-timeout"}
+	// GrpcWebExposeHeaders 浏览器需要读取的 grpc 响应头
+	GrpcWebExposeHeaders = []string{"grpc-status", "grpc-message"}
+)
+
+// grpcServiceRegexp 完整的服务名 package.Service，可以带上方法 package.Service/Method
+var grpcServiceRegexp = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*(\.[A-Za-z_][A-Za-z0-9_]*)*(/[A-Za-z_][A-Za-z0-9_]*)?$`)
+
+// PlusGatewayGrpc grpc 服务在网关上按照服务名和方法路由，不添加前缀也不重写路径
+type PlusGatewayGrpc struct {
+	Services   []string `json:"services,omitempty"`   //package.Service 或者 package.Service/Method，为空时匹配域名下的所有请求
+	MaxTimeout string   `json:"maxTimeout,omitempty"` //网关路由的超时时间，客户端通过 grpc-timeout 请求头设置的超时不能超过该值，0s 表示不限制
+	Web        bool     `json:"web,omitempty"`        //允许浏览器通过 grpc-web 调用
+}
+
+// IsGrpc 所有版本都是 grpc 服务时按照 grpc 路由
+func (r *Plus) IsGrpc() bool {
+	if len(r.Spec.Apps) == 0 {
+		return false
+	}
+	for _, app := range r.Spec.Apps {
+		if app.Protocol != ProtocolGRPC && app.Protocol != ProtocolGRPCWeb {
+			return false
+		}
+	}
+	return true
+}
+
+// IsGrpcRoute 配置了 gateway.grpc 的 grpc 服务在网关上按照服务名和方法路由，
+// 没有配置时和 http 服务一样按照 pathPrefix 匹配，避免占用共享域名下的所有请求
+func (r *Plus) IsGrpcRoute() bool {
+	return r.IsGrpc() && r.Spec.Gateway != nil && r.Spec.Gateway.Grpc != nil
+}
+
+// IsGrpcWeb 网关是否需要支持 grpc-web 调用
+func (r *Plus) IsGrpcWeb() bool {
+	if !r.IsGrpc() {
+		return false
+	}
+	if r.Spec.Gateway != nil && r.Spec.Gateway.Grpc != nil && r.Spec.Gateway.Grpc.Web {
+		return true
+	}
+	for _, app := range r.Spec.Apps {
+		if app.Protocol == ProtocolGRPCWeb {
+			return true
+		}
+	}
+	return false
+}
+
+// GetPrefixes 服务对应的路径前缀 /package.Service/，方法对应完整的路径 /package.Service/Method
+func (r *PlusGatewayGrpc) GetPrefixes() []string {
+	if r == nil || len(r.Services) == 0 {
+		return []string{"/"}
+	}
+	prefixes := make([]string, 0, len(r.Services))
+	for _, service := range r.Services {
+		if strings.Contains(service, "/") {
+			prefixes = append(prefixes, "/"+service)
+		} else {
+			prefixes = append(prefixes, "/"+service+"/")
+		}
+	}
+	return prefixes
+}
+
+// GetURIMatches 服务按照前缀匹配，方法精确匹配
+func (r *PlusGatewayGrpc) GetURIMatches() []*istioapiv1.StringMatch {
+	matches := make([]*istioapiv1.StringMatch, 0)
+	for _, prefix := range r.GetPrefixes() {
+		if strings.HasSuffix(prefix, "/") {
+			matches = append(matches, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Prefix{Prefix: prefix}})
+		} else {
+			matches = append(matches, &istioapiv1.StringMatch{MatchType: &istioapiv1.StringMatch_Exact{Exact: prefix}})
+		}
+	}
+	return matches
+}
+
+// GetMaxTimeout 没有配置时返回 nil，使用 policy 中的超时时间
+func (r *PlusGatewayGrpc) GetMaxTimeout() *duration.Duration {
+	if r == nil {
+		return nil
+	}
+	return parseDuration(r.MaxTimeout)
+}
+
+// WithGrpcWeb 在默认值的基础上追加 grpc-web 需要的请求头和响应头，不修改原配置
+func (r *PlusGatewayCors) WithGrpcWeb() *PlusGatewayCors {
+	if r == nil {
+		return nil
+	}
+	// 先填充默认值，否则追加的请求头会覆盖默认的 allowHeaders
+	cors := r.DeepCopy()
+	cors.Default()
+	for _, header := range GrpcWebAllowHeaders {
+		if !containsString(cors.AllowHeaders, header) {
+			cors.AllowHeaders = append(cors.AllowHeaders, header)
+		}
+	}
+	for _, header := range GrpcWebExposeHeaders {
+		if !containsString(cors.ExposeHeaders, header) {
+			cors.ExposeHeaders = append(cors.ExposeHeaders, header)
+		}
+	}
+	return cors
+}
+
+// validateGrpc grpc 路由只对所有版本都是 grpc 服务的 Plus 生效
+func (r *Plus) validateGrpc(fldPath *field.Path) error {
+	if r.Spec.Gateway == nil || r.Spec.Gateway.Grpc == nil {
+		return nil
+	}
+	fldPath = fldPath.Child("gateway", "grpc")
+
+	if !r.IsGrpc() {
+		err := field.Invalid(fldPath, r.Spec.Gateway.Grpc, "grpc is only supported when all apps use grpc or grpc-web protocol")
+		return apierrors.NewInvalid(PlusKind, "grpc", field.ErrorList{err})
+	}
+	return r.Spec.Gateway.Grpc.Validate(fldPath)
+}
+
+func (r *PlusGatewayGrpc) Validate(fldPath *field.Path) error {
+	for i, service := range r.Services {
+		if !grpcServiceRegexp.MatchString(service) {
+			err := field.Invalid(fldPath.Child("services").Index(i), service, "service must be package.Service or package.Service/Method")
+			return apierrors.NewInvalid(PlusKind, "services", field.ErrorList{err})
+		}
+	}
+	return validateOptionalDuration(fldPath.Child("maxTimeout"), "maxTimeout", r.MaxTimeout)
+}
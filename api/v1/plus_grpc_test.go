@@ -0,0 +1,88 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestValidGrpc(t *testing.T) {
+	plus := func(protocol string, grpc *PlusGatewayGrpc) Plus {
+		return Plus{ObjectMeta: metav1.ObjectMeta{Name: "greeter"}, Spec: PlusSpec{
+			Gateway: &PlusGateway{Hosts: []string{"grpc.a.com"}, Grpc: grpc},
+			Apps:    []*PlusApp{{Version: "blue", MinReplicas: 1, MaxReplicas: 1, Port: 9090, Protocol: protocol}},
+		}}
+	}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: plus(ProtocolGRPC, &PlusGatewayGrpc{Services: []string{"helloworld.Greeter", "helloworld.v1.Admin/Reset"}, MaxTimeout: "30s"}), isErr: false},
+		{r: plus(ProtocolGRPCWeb, &PlusGatewayGrpc{Web: true}), isErr: false},
+		{r: plus(ProtocolHTTP, &PlusGatewayGrpc{}), isErr: true},
+		{r: plus(ProtocolGRPC, &PlusGatewayGrpc{Services: []string{"/helloworld.Greeter"}}), isErr: true},
+		{r: plus(ProtocolGRPC, &PlusGatewayGrpc{Services: []string{"helloworld.Greeter/Say/Hello"}}), isErr: true},
+		{r: plus(ProtocolGRPC, &PlusGatewayGrpc{MaxTimeout: "30"}), isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate()
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestGrpcRoutes(t *testing.T) {
+	plus := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "greeter", Namespace: "default"},
+		Spec: PlusSpec{
+			Gateway: &PlusGateway{
+				Hosts: []string{"grpc.a.com"},
+				Grpc:  &PlusGatewayGrpc{Services: []string{"helloworld.Greeter", "helloworld.Admin/Reset"}},
+			},
+			Apps: []*PlusApp{{Version: "blue", Protocol: ProtocolGRPC}},
+		},
+	}
+	require.True(t, plus.IsGrpc())
+	require.True(t, plus.IsGrpcRoute())
+	require.False(t, plus.IsGrpcWeb())
+
+	require.Equal(t, []string{"/helloworld.Greeter/", "/helloworld.Admin/Reset"}, plus.GetListeners()[0].GetRoutePrefixes(plus))
+	require.Equal(t, []*istioapiv1.StringMatch{
+		{MatchType: &istioapiv1.StringMatch_Prefix{Prefix: "/helloworld.Greeter/"}},
+		{MatchType: &istioapiv1.StringMatch_Exact{Exact: "/helloworld.Admin/Reset"}},
+	}, plus.Spec.Gateway.Grpc.GetURIMatches())
+	require.Len(t, plus.GetGatewayEndpoints(), 2)
+
+	// 没有配置服务时匹配域名下的所有请求
+	var grpc *PlusGatewayGrpc
+	require.Equal(t, []string{"/"}, grpc.GetPrefixes())
+	require.Nil(t, grpc.GetMaxTimeout())
+
+	// 没有配置 gateway.grpc 时按照 pathPrefix 匹配，不占用共享域名下的所有请求
+	plus.Spec.Gateway.Grpc = nil
+	require.True(t, plus.IsGrpc())
+	require.False(t, plus.IsGrpcRoute())
+	require.Equal(t, []string{"/greeter"}, plus.GetListeners()[0].GetRoutePrefixes(plus))
+
+	plus.Spec.Apps = append(plus.Spec.Apps, &PlusApp{Version: "green", Protocol: ProtocolHTTP})
+	require.False(t, plus.IsGrpc())
+	require.Equal(t, []string{"/greeter"}, plus.GetListeners()[0].GetRoutePrefixes(plus))
+}
+
+func TestGrpcWebCors(t *testing.T) {
+	cors := &PlusGatewayCors{AllowOrigins: []string{"https://a.com"}, AllowHeaders: []string{"content-type"}}
+	web := cors.WithGrpcWeb()
+	require.Equal(t, []string{"content-type"}, cors.AllowHeaders)
+	require.Equal(t, GrpcWebAllowHeaders, web.AllowHeaders)
+	require.Equal(t, GrpcWebExposeHeaders, web.ExposeHeaders)
+
+	web = (&PlusGatewayCors{AllowOrigins: []string{"https://a.com"}}).WithGrpcWeb()
+	require.Equal(t, append(append([]string{}, DefaultCorsAllowHeaders...), GrpcWebAllowHeaders...), web.AllowHeaders)
+
+	var empty *PlusGatewayCors
+	require.Nil(t, empty.WithGrpcWeb())
+}
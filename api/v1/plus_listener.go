@@ -0,0 +1,161 @@
+package v1
+
+import (
+	"fmt"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// DefaultGateway 没有指定网关时使用的 istio 网关
+const DefaultGateway = "istio-system/gateway"
+
+// PlusGatewayListener 一组域名的入口，例如公网域名和内网域名使用不同的网关和前缀。
+// 每个 listener 生成独立的 VirtualService <name>-gateway-<listener>
+type PlusGatewayListener struct {
+	Name       string           `json:"name"`                 //listener 名称
+	Hosts      []string         `json:"hosts,omitempty"`      //域名
+	Gateways   []string         `json:"gateways,omitempty"`   //istio 网关 <namespace>/<name>，默认 istio-system/gateway
+	PathPrefix *string          `json:"pathPrefix,omitempty"` //路径前缀，默认和 gateway.pathPrefix 相同
+	Rewrite    string           `json:"rewrite,omitempty"`    //去掉前缀后重写的路径，默认 /
+	Cors       *PlusGatewayCors `json:"cors,omitempty"`       //跨域配置，覆盖 gateway.cors
+	// Delegate 使用 istio 委托，由每个域名的根 VirtualService 按照最长前缀优先转发到该 listener 的 VirtualService
+	Delegate bool `json:"delegate,omitempty"`
+}
+
+// GetListeners 所有生效的 listener，gateway.hosts 不为空时作为第一个(名称为空) listener，对应 <name>-gateway
+func (r *Plus) GetListeners() []*PlusGatewayListener {
+	if r.Spec.Gateway == nil {
+		return nil
+	}
+	listeners := make([]*PlusGatewayListener, 0, len(r.Spec.Gateway.Listeners)+1)
+	if len(r.Spec.Gateway.Hosts) > 0 {
+		listeners = append(listeners, &PlusGatewayListener{
+			Hosts:      r.Spec.Gateway.Hosts,
+			PathPrefix: r.Spec.Gateway.PathPrefix,
+			Delegate:   r.Spec.Gateway.Delegate,
+		})
+	}
+	return append(listeners, r.Spec.Gateway.Listeners...)
+}
+
+// GetVirtualServiceName listener 对应的 VirtualService 名称
+func (r *PlusGatewayListener) GetVirtualServiceName(plus *Plus) string {
+	if r.Name == "" {
+		return plus.GetName() + "-gateway"
+	}
+	return fmt.Sprintf("%s-gateway-%s", plus.GetName(), r.Name)
+}
+
+func (r *PlusGatewayListener) GetGateways() []string {
+	if len(r.Gateways) == 0 {
+		return []string{DefaultGateway}
+	}
+	return r.Gateways
+}
+
+// GetNamespacedGateways listener 使用的网关，统一为 <namespace>/<name>，没有命名空间时为 Plus 所在的命名空间
+func (r *PlusGatewayListener) GetNamespacedGateways(plus *Plus) []string {
+	gateways := make([]string, 0, len(r.GetGateways()))
+	for _, gateway := range r.GetGateways() {
+		namespace, name := ParseNamespacedName(plus.GetNamespace(), gateway)
+		gateways = append(gateways, namespace+"/"+name)
+	}
+	return gateways
+}
+
+// GetPathPrefix 没有配置时使用 gateway.pathPrefix
+func (r *PlusGatewayListener) GetPathPrefix(plus *Plus) string {
+	if r.PathPrefix == nil {
+		return plus.GeneratePrefixPath()
+	}
+	return formatPrefixPath(*r.PathPrefix)
+}
+
+// GetRoutePrefixes listener 在网关上占用的路径前缀，配置了 gateway.grpc 的 grpc 服务为服务名和方法
+func (r *PlusGatewayListener) GetRoutePrefixes(plus *Plus) []string {
+	if plus.IsGrpcRoute() {
+		return plus.Spec.Gateway.Grpc.GetPrefixes()
+	}
+	return []string{r.GetPathPrefix(plus)}
+}
+
+func (r *PlusGatewayListener) GetRewrite() string {
+	if r.Rewrite == "" {
+		return "/"
+	}
+	return r.Rewrite
+}
+
+// GetCors listener 配置了跨域时覆盖 gateway.cors
+func (r *PlusGatewayListener) GetCors(plus *Plus) *PlusGatewayCors {
+	if r.Cors != nil {
+		return r.Cors
+	}
+	return plus.Spec.Gateway.Cors
+}
+
+// GenerateURLs 所有 listener 生效的访问地址
+func (r *Plus) GenerateURLs() []string {
+	if r.Spec.Gateway == nil {
+		return nil
+	}
+	scheme := "http"
+	if r.Spec.Gateway.HttpsRedirect {
+		scheme = "https"
+	}
+	urls := make([]string, 0)
+	for _, listener := range r.GetListeners() {
+		for _, host := range listener.Hosts {
+			for _, prefix := range listener.GetRoutePrefixes(r) {
+				urls = append(urls, fmt.Sprintf("%s://%s%s", scheme, host, prefix))
+			}
+		}
+	}
+	return urls
+}
+
+func formatPrefixPath(prefix string) string {
+	if prefix == "" || strings.HasPrefix(prefix, "/") {
+		return prefix
+	}
+	return "/" + prefix
+}
+
+func (r *PlusGatewayListener) Validate(fldPath *field.Path) error {
+	if errs := validation.IsDNS1123Label(r.Name); len(errs) != 0 {
+		err := field.Invalid(fldPath.Child("name"), r.Name, strings.Join(errs, ","))
+		return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+	}
+
+	if len(r.Hosts) == 0 {
+		err := field.Invalid(fldPath.Child("hosts"), r.Hosts, "hosts can't be empty")
+		return apierrors.NewInvalid(PlusKind, "hosts", field.ErrorList{err})
+	}
+
+	for i, gateway := range r.Gateways {
+		if err := validateNamespacedName(fldPath.Child("gateways").Index(i), "gateways", gateway); err != nil {
+			return err
+		}
+	}
+
+	// 根 VirtualService 和网关在同一个命名空间
+	if r.Delegate && len(r.Gateways) > 1 {
+		err := field.Invalid(fldPath.Child("gateways"), r.Gateways, "delegate only supports one gateway")
+		return apierrors.NewInvalid(PlusKind, "gateways", field.ErrorList{err})
+	}
+
+	if r.Rewrite != "" && !strings.HasPrefix(r.Rewrite, "/") {
+		err := field.Invalid(fldPath.Child("rewrite"), r.Rewrite, "rewrite must start with /")
+		return apierrors.NewInvalid(PlusKind, "rewrite", field.ErrorList{err})
+	}
+
+	if e := r.Cors; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidGatewayListeners(t *testing.T) {
+	listener := func(name string) *PlusGatewayListener {
+		return &PlusGatewayListener{Name: name, Hosts: []string{"api.internal"}}
+	}
+	tests := []struct {
+		r     PlusGateway
+		isErr bool
+	}{
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{listener("internal")}}, isErr: false},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{{Name: "internal", Hosts: []string{"api.internal"}, Gateways: []string{"istio-system/internal-gateway"}, Rewrite: "/api/"}}}, isErr: false},
+		{r: PlusGateway{}, isErr: true},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{listener("internal"), listener("internal")}}, isErr: true},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{listener("Internal")}}, isErr: true},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{{Name: "internal"}}}, isErr: true},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{{Name: "internal", Hosts: []string{"api.internal"}, Rewrite: "api"}}}, isErr: true},
+		{r: PlusGateway{Listeners: []*PlusGatewayListener{{Name: "internal", Hosts: []string{"api.internal"}, Gateways: []string{"a/b/c"}}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestGatewayListeners(t *testing.T) {
+	internalPrefix := "svc"
+	plus := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "default"},
+		Spec: PlusSpec{Gateway: &PlusGateway{
+			Hosts:         []string{"api.a.com", "api.b.com"},
+			HttpsRedirect: true,
+			Cors:          &PlusGatewayCors{AllowOrigins: []string{"https://a.com"}},
+			Listeners: []*PlusGatewayListener{
+				{Name: "internal", Hosts: []string{"api.internal"}, Gateways: []string{"istio-system/internal-gateway"}, PathPrefix: &internalPrefix},
+			},
+		}},
+	}
+
+	listeners := plus.GetListeners()
+	require.Len(t, listeners, 2)
+	require.Equal(t, "svc-a-gateway", listeners[0].GetVirtualServiceName(plus))
+	require.Equal(t, []string{DefaultGateway}, listeners[0].GetGateways())
+	require.Equal(t, "/svc-a", listeners[0].GetPathPrefix(plus))
+	require.Equal(t, "/", listeners[0].GetRewrite())
+	require.Equal(t, "svc-a-gateway-internal", listeners[1].GetVirtualServiceName(plus))
+	require.Equal(t, "/svc", listeners[1].GetPathPrefix(plus))
+	require.Equal(t, plus.Spec.Gateway.Cors, listeners[1].GetCors(plus))
+
+	require.Equal(t, []string{"https://api.a.com/svc-a", "https://api.b.com/svc-a", "https://api.internal/svc"}, plus.GenerateURLs())
+
+	plus.Spec.Gateway.Hosts = nil
+	require.Len(t, plus.GetListeners(), 1)
+}
@@ -0,0 +1,126 @@
+package v1
+
+import (
+	"strings"
+
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// PlusPolicyLocality 按照地域优先访问同可用区的实例，故障时按照优先级转移。
+// istio 只有在配置了熔断(outlierDetection)时才会故障转移
+type PlusPolicyLocality struct {
+	FailoverPriority        []string                       `json:"failoverPriority,omitempty"`        //按照 Pod 标签的优先级转移，如 topology.kubernetes.io/region, topology.kubernetes.io/zone
+	Failover                []PlusPolicyLocalityFailover   `json:"failover,omitempty"`                //region 故障时转移到的 region
+	Distribute              []PlusPolicyLocalityDistribute `json:"distribute,omitempty"`              //按照地域分配流量比例，和 failover 二选一
+	RequireOutlierDetection bool                           `json:"requireOutlierDetection,omitempty"` //要求同时配置 outlierDetection，否则校验失败
+	DisableTopologySpread   bool                           `json:"disableTopologySpread,omitempty"`   //默认每个版本的 Pod 尽量分散到各个可用区，保证有实例可以转移
+}
+
+type PlusPolicyLocalityFailover struct {
+	From string `json:"from"` //region
+	To   string `json:"to"`   //region
+}
+
+type PlusPolicyLocalityDistribute struct {
+	From string            `json:"from"` //region/zone/sub-zone，可以使用 * 通配
+	To   map[string]uint32 `json:"to"`   //地域和流量比例，总和为 100
+}
+
+// GetLocalityLbSetting DestinationRule 中的地域负载均衡配置
+func (r *PlusPolicyLocality) GetLocalityLbSetting() *istioapiv1.LocalityLoadBalancerSetting {
+	if r == nil {
+		return nil
+	}
+	setting := &istioapiv1.LocalityLoadBalancerSetting{
+		FailoverPriority: r.FailoverPriority,
+	}
+	for _, failover := range r.Failover {
+		setting.Failover = append(setting.Failover, &istioapiv1.LocalityLoadBalancerSetting_Failover{From: failover.From, To: failover.To})
+	}
+	for _, distribute := range r.Distribute {
+		setting.Distribute = append(setting.Distribute, &istioapiv1.LocalityLoadBalancerSetting_Distribute{From: distribute.From, To: distribute.To})
+	}
+	return setting
+}
+
+// GetLocality 没有配置时返回 nil
+func (r *Plus) GetLocality() *PlusPolicyLocality {
+	if r.Spec.Policy == nil {
+		return nil
+	}
+	return r.Spec.Policy.Locality
+}
+
+// GetDefaultTopologySpreadConstraints 开启地域负载均衡时，版本的 Pod 尽量分散到各个可用区
+func (r *Plus) GetDefaultTopologySpreadConstraints(app *PlusApp) []corev1.TopologySpreadConstraint {
+	locality := r.GetLocality()
+	if locality == nil || locality.DisableTopologySpread {
+		return nil
+	}
+	return []corev1.TopologySpreadConstraint{{
+		MaxSkew:           1,
+		TopologyKey:       corev1.LabelTopologyZone,
+		WhenUnsatisfiable: corev1.ScheduleAnyway,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: r.GenerateAppLabels(app)},
+	}}
+}
+
+// validateLocality 版本的 outlierDetection 只覆盖该版本，所以要求 spec.policy 中配置 outlierDetection
+func (r *Plus) validateLocality(fldPath *field.Path) error {
+	locality := r.GetLocality()
+	if locality == nil || !locality.RequireOutlierDetection {
+		return nil
+	}
+	if r.Spec.Policy.OutlierDetection == nil {
+		err := field.Required(fldPath.Child("policy", "outlierDetection"), "outlierDetection is required by locality failover")
+		return apierrors.NewInvalid(PlusKind, "outlierDetection", field.ErrorList{err})
+	}
+	return nil
+}
+
+func (r *PlusPolicyLocality) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("locality")
+
+	if len(r.Distribute) > 0 && (len(r.Failover) > 0 || len(r.FailoverPriority) > 0) {
+		err := field.Invalid(fldPath.Child("distribute"), r.Distribute, "distribute and failover can't be set at the same time")
+		return apierrors.NewInvalid(PlusKind, "distribute", field.ErrorList{err})
+	}
+
+	for i, label := range r.FailoverPriority {
+		if label == "" {
+			err := field.Required(fldPath.Child("failoverPriority").Index(i), "label can't be empty")
+			return apierrors.NewInvalid(PlusKind, "failoverPriority", field.ErrorList{err})
+		}
+	}
+
+	for i, failover := range r.Failover {
+		if failover.From == "" || failover.To == "" || failover.From == failover.To {
+			err := field.Invalid(fldPath.Child("failover").Index(i), failover, "from and to must be different regions")
+			return apierrors.NewInvalid(PlusKind, "failover", field.ErrorList{err})
+		}
+		if strings.Contains(failover.From, "/") || strings.Contains(failover.To, "/") {
+			err := field.Invalid(fldPath.Child("failover").Index(i), failover, "failover only supports region")
+			return apierrors.NewInvalid(PlusKind, "failover", field.ErrorList{err})
+		}
+	}
+
+	for i, distribute := range r.Distribute {
+		if distribute.From == "" {
+			err := field.Required(fldPath.Child("distribute").Index(i).Child("from"), "from can't be empty")
+			return apierrors.NewInvalid(PlusKind, "distribute", field.ErrorList{err})
+		}
+		var total uint32
+		for _, weight := range distribute.To {
+			total += weight
+		}
+		if total != 100 {
+			err := field.Invalid(fldPath.Child("distribute").Index(i).Child("to"), distribute.To, "sum of weights must be 100")
+			return apierrors.NewInvalid(PlusKind, "distribute", field.ErrorList{err})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidLocality(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicyLocality
+		isErr bool
+	}{
+		{r: PlusPolicyLocality{}, isErr: false},
+		{r: PlusPolicyLocality{FailoverPriority: []string{"topology.kubernetes.io/region", "topology.kubernetes.io/zone"},
+			Failover: []PlusPolicyLocalityFailover{{From: "cn-east", To: "cn-north"}}}, isErr: false},
+		{r: PlusPolicyLocality{Distribute: []PlusPolicyLocalityDistribute{{From: "cn-east/zone-a/*", To: map[string]uint32{"cn-east/zone-a/*": 80, "cn-east/zone-b/*": 20}}}}, isErr: false},
+		{r: PlusPolicyLocality{FailoverPriority: []string{""}}, isErr: true},
+		{r: PlusPolicyLocality{Failover: []PlusPolicyLocalityFailover{{From: "cn-east", To: "cn-east"}}}, isErr: true},
+		{r: PlusPolicyLocality{Failover: []PlusPolicyLocalityFailover{{From: "cn-east/zone-a", To: "cn-north"}}}, isErr: true},
+		{r: PlusPolicyLocality{Distribute: []PlusPolicyLocalityDistribute{{From: "cn-east/*", To: map[string]uint32{"cn-east/*": 90}}}}, isErr: true},
+		{r: PlusPolicyLocality{Distribute: []PlusPolicyLocalityDistribute{{To: map[string]uint32{"cn-east/*": 100}}}}, isErr: true},
+		{r: PlusPolicyLocality{Failover: []PlusPolicyLocalityFailover{{From: "cn-east", To: "cn-north"}},
+			Distribute: []PlusPolicyLocalityDistribute{{From: "cn-east/*", To: map[string]uint32{"cn-east/*": 100}}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+
+	plus := &Plus{Spec: PlusSpec{Policy: &PlusPolicy{Locality: &PlusPolicyLocality{RequireOutlierDetection: true}}}}
+	require.NotNil(t, plus.validateLocality(field.NewPath("spec")))
+	plus.Spec.Policy.OutlierDetection = &PlusPolicyOutlierDetection{ConsecutiveGatewayErrors: 5}
+	require.Nil(t, plus.validateLocality(field.NewPath("spec")))
+}
+
+func TestLocalitySettings(t *testing.T) {
+	app := &PlusApp{Version: "blue"}
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a"}, Spec: PlusSpec{Apps: []*PlusApp{app}}}
+	require.Nil(t, plus.GetLocality().GetLocalityLbSetting())
+	require.Nil(t, plus.GetDefaultTopologySpreadConstraints(app))
+
+	plus.Spec.Policy = &PlusPolicy{Locality: &PlusPolicyLocality{
+		FailoverPriority: []string{"topology.kubernetes.io/zone"},
+		Failover:         []PlusPolicyLocalityFailover{{From: "cn-east", To: "cn-north"}},
+	}}
+	setting := plus.GetLocality().GetLocalityLbSetting()
+	require.Equal(t, []string{"topology.kubernetes.io/zone"}, setting.FailoverPriority)
+	require.Equal(t, "cn-north", setting.Failover[0].To)
+
+	constraints := plus.GetDefaultTopologySpreadConstraints(app)
+	require.Len(t, constraints, 1)
+	require.Equal(t, corev1.LabelTopologyZone, constraints[0].TopologyKey)
+	require.Equal(t, corev1.ScheduleAnyway, constraints[0].WhenUnsatisfiable)
+	require.Equal(t, plus.GenerateAppLabels(app), constraints[0].LabelSelector.MatchLabels)
+
+	plus.Spec.Policy.Locality.DisableTopologySpread = true
+	require.Nil(t, plus.GetDefaultTopologySpreadConstraints(app))
+}
@@ -1,6 +1,12 @@
 package v1
 
 import (
+	"encoding/json"
+	"fmt"
+	"hash/fnv"
+	"strconv"
+	"strings"
+
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/protobuf/types/known/durationpb"
@@ -9,6 +15,7 @@ import (
 	protobuftypes "github.com/gogo/protobuf/types"
 	istioapiv1 "istio.io/api/networking/v1alpha3"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/util/validation/field"
 )
 
@@ -18,27 +25,128 @@ type PlusPolicy struct {
 	Retries          *PlusPolicyRetries          `json:"retries,omitempty"`
 	Fault            *PlusPolicyFault            `json:"fault,omitempty"`
 	OutlierDetection *PlusPolicyOutlierDetection `json:"outlierDetection,omitempty"`
+	LoadBalancer     *PlusPolicyLoadBalancer     `json:"loadBalancer,omitempty"`
+	ConnectionPool   *PlusPolicyConnectionPool   `json:"connectionPool,omitempty"`
+	RateLimit        *PlusPolicyRateLimit        `json:"rateLimit,omitempty"`
+	Streaming        *PlusPolicyStreaming        `json:"streaming,omitempty"` //websocket 等长连接模式
+	Locality         *PlusPolicyLocality         `json:"locality,omitempty"`  //地域负载均衡和故障转移
+}
+
+// PlusAppPolicy 单个版本的网络策略，覆盖 PlusPolicy 中对应的配置
+type PlusAppPolicy struct {
+	MaxRequest       int32                       `json:"maxRequests,omitempty"`
+	Timeout          string                      `json:"timeout,omitempty"` //总的超时时间
+	Retries          *PlusPolicyRetries          `json:"retries,omitempty"`
+	OutlierDetection *PlusPolicyOutlierDetection `json:"outlierDetection,omitempty"`
+	LoadBalancer     *PlusPolicyLoadBalancer     `json:"loadBalancer,omitempty"`
+	ConnectionPool   *PlusPolicyConnectionPool   `json:"connectionPool,omitempty"`
+	Streaming        *PlusPolicyStreaming        `json:"streaming,omitempty"` //websocket 等长连接模式
+}
+
+// PlusPolicyConnectionPool 连接池配置，http 中未设置的 http1MaxPendingRequests 和 http2MaxRequests 使用 maxRequests
+type PlusPolicyConnectionPool struct {
+	Tcp  *PlusPolicyConnectionPoolTcp  `json:"tcp,omitempty"`
+	Http *PlusPolicyConnectionPoolHttp `json:"http,omitempty"`
+}
+
+type PlusPolicyConnectionPoolTcp struct {
+	MaxConnections int32                   `json:"maxConnections,omitempty"` //最大连接数
+	ConnectTimeout string                  `json:"connectTimeout,omitempty"` //建立连接的超时时间
+	TcpKeepalive   *PlusPolicyTcpKeepalive `json:"tcpKeepalive,omitempty"`   //tcp keepalive
+}
+
+type PlusPolicyTcpKeepalive struct {
+	Probes   uint32 `json:"probes,omitempty"`   //探测失败多少次后认为连接断开
+	Time     string `json:"time,omitempty"`     //连接空闲多久后开始发送探测
+	Interval string `json:"interval,omitempty"` //探测间隔
+}
+
+type PlusPolicyConnectionPoolHttp struct {
+	Http1MaxPendingRequests  int32  `json:"http1MaxPendingRequests,omitempty"`  //http1 最大等待请求数
+	Http2MaxRequests         int32  `json:"http2MaxRequests,omitempty"`         //http2 最大请求数
+	MaxRequestsPerConnection int32  `json:"maxRequestsPerConnection,omitempty"` //每个连接最大请求数，1 表示禁用 keep-alive
+	MaxRetries               int32  `json:"maxRetries,omitempty"`               //所有实例的最大并发重试数
+	IdleTimeout              string `json:"idleTimeout,omitempty"`              //连接空闲超时时间
+	H2UpgradePolicy          string `json:"h2UpgradePolicy,omitempty"`          //DEFAULT,DO_NOT_UPGRADE,UPGRADE
+}
+
+var h2UpgradePolicies = map[string]istioapiv1.ConnectionPoolSettings_HTTPSettings_H2UpgradePolicy{
+	"DEFAULT":        istioapiv1.ConnectionPoolSettings_HTTPSettings_DEFAULT,
+	"DO_NOT_UPGRADE": istioapiv1.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE,
+	"UPGRADE":        istioapiv1.ConnectionPoolSettings_HTTPSettings_UPGRADE,
+}
+
+type PlusPolicyLoadBalancer struct {
+	Simple         string                                `json:"simple,omitempty"`         //负载均衡算法 ROUND_ROBIN,LEAST_REQUEST,RANDOM,PASSTHROUGH
+	ConsistentHash *PlusPolicyLoadBalancerConsistentHash `json:"consistentHash,omitempty"` //一致性哈希(会话保持)，和 simple 二选一
+	WarmupDuration string                                `json:"warmupDuration,omitempty"` //新实例预热时间
+}
+
+type PlusPolicyLoadBalancerConsistentHash struct {
+	HttpHeaderName         string                `json:"httpHeaderName,omitempty"`         //根据请求头哈希
+	HttpCookie             *PlusPolicyHttpCookie `json:"httpCookie,omitempty"`             //根据 cookie 哈希，cookie 不存在时由 envoy 生成
+	UseSourceIp            bool                  `json:"useSourceIp,omitempty"`            //根据来源 IP 哈希
+	HttpQueryParameterName string                `json:"httpQueryParameterName,omitempty"` //根据查询参数哈希
+	MinimumRingSize        uint64                `json:"minimumRingSize,omitempty"`        //哈希环的最小长度
+}
+
+type PlusPolicyHttpCookie struct {
+	Name string `json:"name,omitempty"`
+	Path string `json:"path,omitempty"`
+	Ttl  string `json:"ttl,omitempty"`
+}
+
+var simpleLoadBalancers = map[string]istioapiv1.LoadBalancerSettings_SimpleLB{
+	"ROUND_ROBIN":   istioapiv1.LoadBalancerSettings_ROUND_ROBIN,
+	"LEAST_REQUEST": istioapiv1.LoadBalancerSettings_LEAST_REQUEST,
+	"RANDOM":        istioapiv1.LoadBalancerSettings_RANDOM,
+	"PASSTHROUGH":   istioapiv1.LoadBalancerSettings_PASSTHROUGH,
 }
 
 type PlusPolicyRetries struct {
-	Attempts      int32  `json:"attempts,omitempty"`      //重试次数
-	PerTryTimeout string `json:"perTryTimeout,omitempty"` //每次重试的超时时间
-	RetryOn       string `json:"retryOn,omitempty"`       //重试发生于什么错误
+	Attempts              int32  `json:"attempts,omitempty"`              //重试次数
+	PerTryTimeout         string `json:"perTryTimeout,omitempty"`         //每次重试的超时时间
+	RetryOn               string `json:"retryOn,omitempty"`               //重试发生于什么错误，多个用逗号分隔
+	RetryRemoteLocalities *bool  `json:"retryRemoteLocalities,omitempty"` //是否允许重试到其他地域的实例
 }
 
 type PlusPolicyOutlierDetection struct {
-	ConsecutiveErrors  uint32 `json:"consecutiveErrors,omitempty"` //熔断错误数量
-	Interval           string `json:"interval,omitempty"`          //检查间隔
-	EjectionTime       string `json:"ejectionTime,omitempty"`      //驱逐时间
-	MaxEjectionPercent int32  `json:"ejectionPercent,omitempty"`   //服务的可驱逐故障实例的最大比例
-	MinHealthPercent   int32  `json:"minHealthPercent,omitempty"`  //最小健康比例，健康的实例数量低于这个比例,异常检查功能
+	ConsecutiveErrors              uint32 `json:"consecutiveErrors,omitempty"`              //熔断错误数量(5xx)
+	ConsecutiveGatewayErrors       uint32 `json:"consecutiveGatewayErrors,omitempty"`       //熔断网关错误数量(502,503,504)
+	ConsecutiveLocalOriginFailures uint32 `json:"consecutiveLocalOriginFailures,omitempty"` //熔断本地错误数量(连接超时、连接重置等)，需要开启 splitExternalLocalOriginErrors
+	SplitExternalLocalOriginErrors bool   `json:"splitExternalLocalOriginErrors,omitempty"` //区分本地错误和上游返回的错误
+	Interval                       string `json:"interval,omitempty"`                       //检查间隔
+	EjectionTime                   string `json:"ejectionTime,omitempty"`                   //驱逐时间
+	MaxEjectionPercent             int32  `json:"ejectionPercent,omitempty"`                //服务的可驱逐故障实例的最大比例
+	MinHealthPercent               int32  `json:"minHealthPercent,omitempty"`               //最小健康比例，健康的实例数量低于这个比例,异常检查功能
+}
+
+// retryOnConditions envoy 支持的 http 和 grpc 重试条件
+var retryOnConditions = []string{
+	"5xx", "gateway-error", "reset", "connect-failure", "envoy-ratelimited", "retriable-4xx",
+	"refused-stream", "retriable-status-codes", "retriable-headers", "http3-post-connect-failure",
+	"cancelled", "deadline-exceeded", "internal", "resource-exhausted", "unavailable",
 }
 
 type PlusPolicyFault struct {
-	Delay *PlusPolicyFaultDelay `json:"delay,omitempty"`
-	Abort *PlusPolicyFaultAbort `json:"abort,omitempty"`
+	Delay        *PlusPolicyFaultDelay `json:"delay,omitempty"`
+	Abort        *PlusPolicyFaultAbort `json:"abort,omitempty"`
+	Scope        string                `json:"scope,omitempty"`        //生效范围 all(默认),mesh,gateway
+	Versions     []string              `json:"versions,omitempty"`     //只对这些版本生效，为空时对所有版本生效(包括网关按权重分流的默认路由)
+	HeadersMatch map[string]string     `json:"headersMatch,omitempty"` //只对请求头匹配的请求生效
+	ExpiresAt    *metav1.Time          `json:"expiresAt,omitempty"`    //过期时间，过期后控制器自动移除故障注入
+	Duration     string                `json:"duration,omitempty"`     //持续时间，从控制器首次生效开始计算，和 expiresAt 二选一
 }
 
+// MaxRetryAttempts 重试次数的上限，没有设置 timeout 时也会生效，避免重试放大故障
+const MaxRetryAttempts = 10
+
+const (
+	FaultScopeAll     = "all"
+	FaultScopeMesh    = "mesh"
+	FaultScopeGateway = "gateway"
+)
+
 type PlusPolicyFaultDelay struct {
 	Percent *int32 `json:"percent,omitempty"`
 	Delay   string `json:"delay,omitempty"`
@@ -47,6 +155,13 @@ type PlusPolicyFaultDelay struct {
 type PlusPolicyFaultAbort struct {
 	Percent    *int32 `json:"percent,omitempty"`
 	HttpStatus int32  `json:"httpStatus,omitempty"`
+	GrpcStatus string `json:"grpcStatus,omitempty"` //grpc 状态码，如 UNAVAILABLE，和 httpStatus 二选一
+}
+
+var grpcStatuses = []string{
+	"OK", "CANCELLED", "UNKNOWN", "INVALID_ARGUMENT", "DEADLINE_EXCEEDED", "NOT_FOUND", "ALREADY_EXISTS",
+	"PERMISSION_DENIED", "RESOURCE_EXHAUSTED", "FAILED_PRECONDITION", "ABORTED", "OUT_OF_RANGE",
+	"UNIMPLEMENTED", "INTERNAL", "UNAVAILABLE", "DATA_LOSS", "UNAUTHENTICATED",
 }
 
 func (d *PlusPolicy) GetTimeout() *duration.Duration {
@@ -64,6 +179,14 @@ func (d *PlusPolicy) GetTimeout() *duration.Duration {
 	}
 }
 
+func (d *PlusAppPolicy) GetTimeout() *duration.Duration {
+	timeout := parseDuration(d.Timeout)
+	if timeout == nil || (timeout.Seconds == 0 && timeout.Nanos == 0) {
+		return nil
+	}
+	return timeout
+}
+
 func (d *PlusPolicyRetries) GetPerTryTimeout() *duration.Duration {
 	time, err := time.ParseDuration(d.PerTryTimeout)
 	if err != nil {
@@ -76,10 +199,31 @@ func (d *PlusPolicyRetries) GetPerTryTimeout() *duration.Duration {
 	}
 }
 
+func (d *PlusPolicyRetries) GetRetryRemoteLocalities() *wrappers.BoolValue {
+	if d.RetryRemoteLocalities == nil {
+		return nil
+	}
+	return &wrappers.BoolValue{Value: *d.RetryRemoteLocalities}
+}
+
 func (r *PlusPolicyOutlierDetection) GetConsecutiveErrors() *wrappers.UInt32Value {
 	return &wrappers.UInt32Value{Value: r.ConsecutiveErrors}
 }
 
+func (r *PlusPolicyOutlierDetection) GetConsecutiveGatewayErrors() *wrappers.UInt32Value {
+	if r.ConsecutiveGatewayErrors == 0 {
+		return nil
+	}
+	return &wrappers.UInt32Value{Value: r.ConsecutiveGatewayErrors}
+}
+
+func (r *PlusPolicyOutlierDetection) GetConsecutiveLocalOriginFailures() *wrappers.UInt32Value {
+	if r.ConsecutiveLocalOriginFailures == 0 {
+		return nil
+	}
+	return &wrappers.UInt32Value{Value: r.ConsecutiveLocalOriginFailures}
+}
+
 func (r *PlusPolicyOutlierDetection) GetInterval() *duration.Duration {
 	time, err := time.ParseDuration(r.Interval)
 	if err != nil {
@@ -126,6 +270,57 @@ func (d *PlusPolicyFaultDelay) GetPercent() *istioapiv1.Percent {
 	}
 }
 
+// InScope 判断故障是否对该版本、网关或网格内的路由生效，app 为 nil 表示网关按权重分流的默认路由
+func (d *PlusPolicyFault) InScope(app *PlusApp, isGateway bool) bool {
+	switch d.Scope {
+	case FaultScopeMesh:
+		if isGateway {
+			return false
+		}
+	case FaultScopeGateway:
+		if !isGateway {
+			return false
+		}
+	}
+
+	if len(d.Versions) == 0 {
+		return true
+	}
+	if app == nil {
+		return false
+	}
+	for _, v := range d.Versions {
+		if v == app.Version {
+			return true
+		}
+	}
+	return false
+}
+
+// GetExpiresAt 计算故障的过期时间，没有配置过期时间时返回 nil
+func (d *PlusPolicyFault) GetExpiresAt(startedAt metav1.Time) *metav1.Time {
+	if d.ExpiresAt != nil {
+		return d.ExpiresAt
+	}
+	if d.Duration == "" {
+		return nil
+	}
+	t, err := time.ParseDuration(d.Duration)
+	if err != nil {
+		return nil
+	}
+	expiresAt := metav1.NewTime(startedAt.Add(t))
+	return &expiresAt
+}
+
+// Hash 故障配置的摘要，配置变化后重新开始实验窗口
+func (d *PlusPolicyFault) Hash() string {
+	data, _ := json.Marshal(d)
+	h := fnv.New32a()
+	_, _ = h.Write(data)
+	return strconv.FormatUint(uint64(h.Sum32()), 16)
+}
+
 func (d *PlusPolicyFaultAbort) GetPercent() *istioapiv1.Percent {
 	var value float64
 	if d.Percent != nil {
@@ -136,6 +331,106 @@ func (d *PlusPolicyFaultAbort) GetPercent() *istioapiv1.Percent {
 	}
 }
 
+func (d *PlusPolicyLoadBalancer) GetLoadBalancerSettings() *istioapiv1.LoadBalancerSettings {
+	settings := &istioapiv1.LoadBalancerSettings{
+		WarmupDurationSecs: parseDuration(d.WarmupDuration),
+	}
+	if d.ConsistentHash != nil {
+		settings.LbPolicy = &istioapiv1.LoadBalancerSettings_ConsistentHash{
+			ConsistentHash: d.ConsistentHash.GetConsistentHash(),
+		}
+		return settings
+	}
+
+	simple, ok := simpleLoadBalancers[d.Simple]
+	if !ok {
+		simple = istioapiv1.LoadBalancerSettings_ROUND_ROBIN
+	}
+	settings.LbPolicy = &istioapiv1.LoadBalancerSettings_Simple{Simple: simple}
+	return settings
+}
+
+func (d *PlusPolicyLoadBalancerConsistentHash) GetConsistentHash() *istioapiv1.LoadBalancerSettings_ConsistentHashLB {
+	lb := &istioapiv1.LoadBalancerSettings_ConsistentHashLB{
+		MinimumRingSize: d.MinimumRingSize,
+	}
+	switch {
+	case d.HttpHeaderName != "":
+		lb.HashKey = &istioapiv1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{HttpHeaderName: d.HttpHeaderName}
+	case d.HttpCookie != nil:
+		lb.HashKey = &istioapiv1.LoadBalancerSettings_ConsistentHashLB_HttpCookie{
+			HttpCookie: &istioapiv1.LoadBalancerSettings_ConsistentHashLB_HTTPCookie{
+				Name: d.HttpCookie.Name,
+				Path: d.HttpCookie.Path,
+				Ttl:  parseDuration(d.HttpCookie.Ttl),
+			},
+		}
+	case d.UseSourceIp:
+		lb.HashKey = &istioapiv1.LoadBalancerSettings_ConsistentHashLB_UseSourceIp{UseSourceIp: true}
+	case d.HttpQueryParameterName != "":
+		lb.HashKey = &istioapiv1.LoadBalancerSettings_ConsistentHashLB_HttpQueryParameterName{HttpQueryParameterName: d.HttpQueryParameterName}
+	}
+	return lb
+}
+
+// GetConnectionPoolSettings 转换为 istio 连接池配置，maxRequests 作为 http 并发请求数的默认值
+func (d *PlusPolicyConnectionPool) GetConnectionPoolSettings(maxRequest int32) *istioapiv1.ConnectionPoolSettings {
+	settings := &istioapiv1.ConnectionPoolSettings{
+		Http: &istioapiv1.ConnectionPoolSettings_HTTPSettings{
+			Http1MaxPendingRequests:  maxRequest,
+			Http2MaxRequests:         maxRequest,
+			MaxRequestsPerConnection: 0,
+		},
+	}
+	if d == nil {
+		return settings
+	}
+
+	if d.Tcp != nil {
+		settings.Tcp = &istioapiv1.ConnectionPoolSettings_TCPSettings{
+			MaxConnections: d.Tcp.MaxConnections,
+			ConnectTimeout: parseDuration(d.Tcp.ConnectTimeout),
+		}
+		if d.Tcp.TcpKeepalive != nil {
+			settings.Tcp.TcpKeepalive = &istioapiv1.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+				Probes:   d.Tcp.TcpKeepalive.Probes,
+				Time:     parseDuration(d.Tcp.TcpKeepalive.Time),
+				Interval: parseDuration(d.Tcp.TcpKeepalive.Interval),
+			}
+		}
+	}
+
+	if d.Http != nil {
+		if d.Http.Http1MaxPendingRequests > 0 {
+			settings.Http.Http1MaxPendingRequests = d.Http.Http1MaxPendingRequests
+		}
+		if d.Http.Http2MaxRequests > 0 {
+			settings.Http.Http2MaxRequests = d.Http.Http2MaxRequests
+		}
+		settings.Http.MaxRequestsPerConnection = d.Http.MaxRequestsPerConnection
+		settings.Http.MaxRetries = d.Http.MaxRetries
+		settings.Http.IdleTimeout = parseDuration(d.Http.IdleTimeout)
+		settings.Http.H2UpgradePolicy = h2UpgradePolicies[d.Http.H2UpgradePolicy]
+	}
+	return settings
+}
+
+// parseDuration 解析时间字符串，为空或者格式错误时返回 nil
+func parseDuration(s string) *duration.Duration {
+	if s == "" {
+		return nil
+	}
+	t, err := time.ParseDuration(s)
+	if err != nil {
+		return nil
+	}
+	dd := protobuftypes.DurationProto(t)
+	return &duration.Duration{
+		Seconds: dd.Seconds,
+		Nanos:   dd.Nanos,
+	}
+}
+
 func (d *PlusPolicy) Validate(fldPath *field.Path) error {
 	fldPath = fldPath.Child("policy")
 
@@ -154,6 +449,9 @@ func (d *PlusPolicy) Validate(fldPath *field.Path) error {
 		if err := e.Validate(fldPath); err != nil {
 			return err
 		}
+		if err := validateRetryBudget(fldPath, e, d.Timeout); err != nil {
+			return err
+		}
 	}
 
 	if e := d.OutlierDetection; e != nil {
@@ -162,6 +460,235 @@ func (d *PlusPolicy) Validate(fldPath *field.Path) error {
 		}
 	}
 
+	if e := d.LoadBalancer; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.ConnectionPool; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.RateLimit; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.Streaming; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.Locality; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *PlusAppPolicy) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("policy")
+
+	if d.MaxRequest < 0 {
+		err := field.Invalid(fldPath.Child("maxRequests"), d.MaxRequest, "maxRequests must >= 0")
+		return apierrors.NewInvalid(PlusKind, "maxRequests", field.ErrorList{err})
+	}
+
+	if d.Timeout != "" {
+		if _, err := time.ParseDuration(d.Timeout); err != nil {
+			err := field.Invalid(fldPath.Child("timeout"), d.Timeout, err.Error())
+			return apierrors.NewInvalid(PlusKind, "timeout", field.ErrorList{err})
+		}
+	}
+
+	if e := d.Retries; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+		if err := validateRetryBudget(fldPath, e, d.Timeout); err != nil {
+			return err
+		}
+	}
+
+	if e := d.OutlierDetection; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.LoadBalancer; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.ConnectionPool; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.Streaming; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *PlusPolicyConnectionPool) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("connectionPool")
+
+	if e := d.Tcp; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := d.Http; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *PlusPolicyConnectionPoolTcp) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("tcp")
+
+	if d.MaxConnections < 0 {
+		err := field.Invalid(fldPath.Child("maxConnections"), d.MaxConnections, "maxConnections must >= 0")
+		return apierrors.NewInvalid(PlusKind, "maxConnections", field.ErrorList{err})
+	}
+
+	if err := validateOptionalDuration(fldPath.Child("connectTimeout"), "connectTimeout", d.ConnectTimeout); err != nil {
+		return err
+	}
+
+	if e := d.TcpKeepalive; e != nil {
+		if err := validateOptionalDuration(fldPath.Child("tcpKeepalive", "time"), "time", e.Time); err != nil {
+			return err
+		}
+		if err := validateOptionalDuration(fldPath.Child("tcpKeepalive", "interval"), "interval", e.Interval); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *PlusPolicyConnectionPoolHttp) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("http")
+
+	for _, v := range []struct {
+		name  string
+		value int32
+	}{
+		{"http1MaxPendingRequests", d.Http1MaxPendingRequests},
+		{"http2MaxRequests", d.Http2MaxRequests},
+		{"maxRequestsPerConnection", d.MaxRequestsPerConnection},
+		{"maxRetries", d.MaxRetries},
+	} {
+		if v.value < 0 {
+			err := field.Invalid(fldPath.Child(v.name), v.value, v.name+" must >= 0")
+			return apierrors.NewInvalid(PlusKind, v.name, field.ErrorList{err})
+		}
+	}
+
+	if err := validateOptionalDuration(fldPath.Child("idleTimeout"), "idleTimeout", d.IdleTimeout); err != nil {
+		return err
+	}
+
+	if _, ok := h2UpgradePolicies[d.H2UpgradePolicy]; d.H2UpgradePolicy != "" && !ok {
+		err := field.NotSupported(fldPath.Child("h2UpgradePolicy"), d.H2UpgradePolicy, []string{"DEFAULT", "DO_NOT_UPGRADE", "UPGRADE"})
+		return apierrors.NewInvalid(PlusKind, "h2UpgradePolicy", field.ErrorList{err})
+	}
+
+	return nil
+}
+
+// validateOptionalDuration 校验可以为空的时间字符串，name 为字段名
+func validateOptionalDuration(fldPath *field.Path, name, value string) error {
+	if value == "" {
+		return nil
+	}
+	if _, err := time.ParseDuration(value); err != nil {
+		err := field.Invalid(fldPath, value, err.Error())
+		return apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+	}
+	return nil
+}
+
+func (d *PlusPolicyLoadBalancer) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("loadBalancer")
+
+	if d.Simple != "" && d.ConsistentHash != nil {
+		err := field.Invalid(fldPath, d.Simple, "simple and consistentHash can't be set at the same time")
+		return apierrors.NewInvalid(PlusKind, "loadBalancer", field.ErrorList{err})
+	}
+
+	if _, ok := simpleLoadBalancers[d.Simple]; d.Simple != "" && !ok {
+		err := field.NotSupported(fldPath.Child("simple"), d.Simple, []string{"ROUND_ROBIN", "LEAST_REQUEST", "RANDOM", "PASSTHROUGH"})
+		return apierrors.NewInvalid(PlusKind, "simple", field.ErrorList{err})
+	}
+
+	if d.WarmupDuration != "" {
+		if _, err := time.ParseDuration(d.WarmupDuration); err != nil {
+			err := field.Invalid(fldPath.Child("warmupDuration"), d.WarmupDuration, err.Error())
+			return apierrors.NewInvalid(PlusKind, "warmupDuration", field.ErrorList{err})
+		}
+	}
+
+	if e := d.ConsistentHash; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (d *PlusPolicyLoadBalancerConsistentHash) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("consistentHash")
+
+	keys := 0
+	if d.HttpHeaderName != "" {
+		keys++
+	}
+	if d.HttpCookie != nil {
+		keys++
+	}
+	if d.UseSourceIp {
+		keys++
+	}
+	if d.HttpQueryParameterName != "" {
+		keys++
+	}
+	if keys != 1 {
+		err := field.Invalid(fldPath, keys, "exactly one of httpHeaderName, httpCookie, useSourceIp, httpQueryParameterName must be set")
+		return apierrors.NewInvalid(PlusKind, "consistentHash", field.ErrorList{err})
+	}
+
+	if e := d.HttpCookie; e != nil {
+		if e.Name == "" {
+			err := field.Invalid(fldPath.Child("httpCookie", "name"), e.Name, "name can't be empty")
+			return apierrors.NewInvalid(PlusKind, "httpCookie", field.ErrorList{err})
+		}
+		if _, err := time.ParseDuration(e.Ttl); err != nil {
+			err := field.Invalid(fldPath.Child("httpCookie", "ttl"), e.Ttl, err.Error())
+			return apierrors.NewInvalid(PlusKind, "ttl", field.ErrorList{err})
+		}
+	}
+
 	return nil
 }
 
@@ -173,16 +700,74 @@ func (d *PlusPolicyRetries) Validate(fldPath *field.Path) error {
 		return apierrors.NewInvalid(PlusKind, "perTryTimeout", field.ErrorList{err})
 	}
 
-	if d.Attempts <= 0 {
-		err := field.Invalid(fldPath.Child("attempts"), d.Attempts, "attempts must > 0")
+	if d.Attempts <= 0 || d.Attempts > MaxRetryAttempts {
+		err := field.Invalid(fldPath.Child("attempts"), d.Attempts, fmt.Sprintf("attempts must > 0 and <= %d", MaxRetryAttempts))
 		return apierrors.NewInvalid(PlusKind, "attempts", field.ErrorList{err})
 	}
 
+	if d.RetryOn != "" {
+		for _, condition := range strings.Split(d.RetryOn, ",") {
+			condition = strings.TrimSpace(condition)
+			if isRetryOnCondition(condition) {
+				continue
+			}
+			err := field.NotSupported(fldPath.Child("retryOn"), condition, append(retryOnConditions, "<http status code>"))
+			return apierrors.NewInvalid(PlusKind, "retryOn", field.ErrorList{err})
+		}
+	}
+
+	return nil
+}
+
+func isRetryOnCondition(condition string) bool {
+	for _, c := range retryOnConditions {
+		if c == condition {
+			return true
+		}
+	}
+	// istio 允许直接使用 http 状态码作为重试条件
+	code, err := strconv.Atoi(condition)
+	return err == nil && code >= 100 && code <= 599
+}
+
+// validateRetryBudget 校验所有重试的总耗时(attempts * perTryTimeout)不超过总的超时时间
+func validateRetryBudget(fldPath *field.Path, retries *PlusPolicyRetries, timeout string) error {
+	if retries == nil || timeout == "" {
+		return nil
+	}
+	total, err := time.ParseDuration(timeout)
+	if err != nil || total == 0 {
+		return nil
+	}
+	perTry, err := time.ParseDuration(retries.PerTryTimeout)
+	if err != nil {
+		return nil
+	}
+	if budget := time.Duration(retries.Attempts) * perTry; budget > total {
+		err := field.Invalid(fldPath.Child("retries"), fmt.Sprintf("%d * %s", retries.Attempts, retries.PerTryTimeout),
+			fmt.Sprintf("attempts * perTryTimeout(%s) must <= timeout(%s)", budget, total))
+		return apierrors.NewInvalid(PlusKind, "retries", field.ErrorList{err})
+	}
 	return nil
 }
 
 func (d *PlusPolicyFault) Validate(fldPath *field.Path) error {
 	fldPath = fldPath.Child("fault")
+
+	if d.Scope != "" && d.Scope != FaultScopeAll && d.Scope != FaultScopeMesh && d.Scope != FaultScopeGateway {
+		err := field.NotSupported(fldPath.Child("scope"), d.Scope, []string{FaultScopeAll, FaultScopeMesh, FaultScopeGateway})
+		return apierrors.NewInvalid(PlusKind, "scope", field.ErrorList{err})
+	}
+
+	if d.ExpiresAt != nil && d.Duration != "" {
+		err := field.Invalid(fldPath.Child("duration"), d.Duration, "expiresAt and duration can't be set at the same time")
+		return apierrors.NewInvalid(PlusKind, "duration", field.ErrorList{err})
+	}
+
+	if err := validateOptionalDuration(fldPath.Child("duration"), "duration", d.Duration); err != nil {
+		return err
+	}
+
 	if e := d.Delay; e != nil {
 		if err := e.Validate(fldPath); err != nil {
 			return err
@@ -209,7 +794,23 @@ func (d *PlusPolicyFaultDelay) Validate(fldPath *field.Path) error {
 
 func (d *PlusPolicyFaultAbort) Validate(fldPath *field.Path) error {
 	fldPath = fldPath.Child("abort")
-	return nil
+
+	if d.GrpcStatus == "" {
+		return nil
+	}
+
+	if d.HttpStatus != 0 {
+		err := field.Invalid(fldPath.Child("grpcStatus"), d.GrpcStatus, "httpStatus and grpcStatus can't be set at the same time")
+		return apierrors.NewInvalid(PlusKind, "grpcStatus", field.ErrorList{err})
+	}
+
+	for _, status := range grpcStatuses {
+		if status == d.GrpcStatus {
+			return nil
+		}
+	}
+	err := field.NotSupported(fldPath.Child("grpcStatus"), d.GrpcStatus, grpcStatuses)
+	return apierrors.NewInvalid(PlusKind, "grpcStatus", field.ErrorList{err})
 }
 
 func (r *PlusPolicyOutlierDetection) Validate(fldPath *field.Path) error {
@@ -225,10 +826,15 @@ func (r *PlusPolicyOutlierDetection) Validate(fldPath *field.Path) error {
 		return apierrors.NewInvalid(PlusKind, "ejectionTime", field.ErrorList{err})
 	}
 
-	if r.ConsecutiveErrors <= 0 {
-		err := field.Invalid(fldPath.Child("consecutiveErrors"), r.ConsecutiveErrors, "consecutiveErrors must > 0")
+	if r.ConsecutiveErrors <= 0 && r.ConsecutiveGatewayErrors <= 0 && r.ConsecutiveLocalOriginFailures <= 0 {
+		err := field.Invalid(fldPath.Child("consecutiveErrors"), r.ConsecutiveErrors, "one of consecutiveErrors, consecutiveGatewayErrors, consecutiveLocalOriginFailures must > 0")
 		return apierrors.NewInvalid(PlusKind, "consecutiveErrors", field.ErrorList{err})
 	}
 
+	if r.ConsecutiveLocalOriginFailures > 0 && !r.SplitExternalLocalOriginErrors {
+		err := field.Invalid(fldPath.Child("consecutiveLocalOriginFailures"), r.ConsecutiveLocalOriginFailures, "consecutiveLocalOriginFailures requires splitExternalLocalOriginErrors")
+		return apierrors.NewInvalid(PlusKind, "consecutiveLocalOriginFailures", field.ErrorList{err})
+	}
+
 	return nil
 }
@@ -0,0 +1,153 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidLoadBalancer(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicyLoadBalancer
+		isErr bool
+	}{
+		{r: PlusPolicyLoadBalancer{Simple: "LEAST_REQUEST", WarmupDuration: "30s"}, isErr: false},
+		{r: PlusPolicyLoadBalancer{ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{HttpHeaderName: "x-user-id"}}, isErr: false},
+		{r: PlusPolicyLoadBalancer{ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{HttpCookie: &PlusPolicyHttpCookie{Name: "session", Ttl: "1h"}}}, isErr: false},
+		{r: PlusPolicyLoadBalancer{Simple: "LEAST_CONN"}, isErr: true},
+		{r: PlusPolicyLoadBalancer{Simple: "RANDOM", ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{UseSourceIp: true}}, isErr: true},
+		{r: PlusPolicyLoadBalancer{ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{}}, isErr: true},
+		{r: PlusPolicyLoadBalancer{ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{UseSourceIp: true, HttpHeaderName: "x-user-id"}}, isErr: true},
+		{r: PlusPolicyLoadBalancer{ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{HttpCookie: &PlusPolicyHttpCookie{Name: "session"}}}, isErr: true},
+		{r: PlusPolicyLoadBalancer{WarmupDuration: "30"}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestGetLoadBalancerSettings(t *testing.T) {
+	lb := (&PlusPolicyLoadBalancer{}).GetLoadBalancerSettings()
+	require.Equal(t, istioapiv1.LoadBalancerSettings_ROUND_ROBIN, lb.GetSimple())
+	require.Nil(t, lb.WarmupDurationSecs)
+
+	lb = (&PlusPolicyLoadBalancer{
+		ConsistentHash: &PlusPolicyLoadBalancerConsistentHash{HttpCookie: &PlusPolicyHttpCookie{Name: "session", Ttl: "1h"}},
+		WarmupDuration: "1m",
+	}).GetLoadBalancerSettings()
+	require.Equal(t, "session", lb.GetConsistentHash().GetHttpCookie().Name)
+	require.Equal(t, int64(3600), lb.GetConsistentHash().GetHttpCookie().Ttl.Seconds)
+	require.Equal(t, int64(60), lb.WarmupDurationSecs.Seconds)
+}
+
+func TestValidConnectionPool(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicyConnectionPool
+		isErr bool
+	}{
+		{r: PlusPolicyConnectionPool{
+			Tcp:  &PlusPolicyConnectionPoolTcp{MaxConnections: 100, ConnectTimeout: "3s", TcpKeepalive: &PlusPolicyTcpKeepalive{Time: "7200s", Interval: "75s"}},
+			Http: &PlusPolicyConnectionPoolHttp{MaxRequestsPerConnection: 10, IdleTimeout: "1h", H2UpgradePolicy: "UPGRADE"},
+		}, isErr: false},
+		{r: PlusPolicyConnectionPool{Tcp: &PlusPolicyConnectionPoolTcp{MaxConnections: -1}}, isErr: true},
+		{r: PlusPolicyConnectionPool{Tcp: &PlusPolicyConnectionPoolTcp{ConnectTimeout: "3"}}, isErr: true},
+		{r: PlusPolicyConnectionPool{Http: &PlusPolicyConnectionPoolHttp{MaxRetries: -1}}, isErr: true},
+		{r: PlusPolicyConnectionPool{Http: &PlusPolicyConnectionPoolHttp{H2UpgradePolicy: "ALWAYS"}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestGetConnectionPoolSettings(t *testing.T) {
+	var pool *PlusPolicyConnectionPool
+	settings := pool.GetConnectionPoolSettings(1000)
+	require.Nil(t, settings.Tcp)
+	require.Equal(t, int32(1000), settings.Http.Http1MaxPendingRequests)
+	require.Equal(t, int32(1000), settings.Http.Http2MaxRequests)
+
+	pool = &PlusPolicyConnectionPool{
+		Tcp:  &PlusPolicyConnectionPoolTcp{MaxConnections: 100, ConnectTimeout: "3s"},
+		Http: &PlusPolicyConnectionPoolHttp{Http2MaxRequests: 200, IdleTimeout: "30s", H2UpgradePolicy: "DO_NOT_UPGRADE"},
+	}
+	settings = pool.GetConnectionPoolSettings(1000)
+	require.Equal(t, int32(100), settings.Tcp.MaxConnections)
+	require.Equal(t, int64(3), settings.Tcp.ConnectTimeout.Seconds)
+	require.Equal(t, int32(1000), settings.Http.Http1MaxPendingRequests)
+	require.Equal(t, int32(200), settings.Http.Http2MaxRequests)
+	require.Equal(t, istioapiv1.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE, settings.Http.H2UpgradePolicy)
+}
+
+func TestValidRetries(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicy
+		isErr bool
+	}{
+		{r: PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 3, PerTryTimeout: "2s", RetryOn: "5xx,connect-failure"}}, isErr: false},
+		{r: PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 3, PerTryTimeout: "2s", RetryOn: "unavailable, resource-exhausted,503"}}, isErr: false},
+		{r: PlusPolicy{Timeout: "0s", Retries: &PlusPolicyRetries{Attempts: 10, PerTryTimeout: "2s"}}, isErr: false},
+		{r: PlusPolicy{Timeout: "0s", Retries: &PlusPolicyRetries{Attempts: 50, PerTryTimeout: "2s"}}, isErr: true},
+		{r: PlusPolicy{Retries: &PlusPolicyRetries{Attempts: 11, PerTryTimeout: "1s"}}, isErr: true},
+		{r: PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 3, PerTryTimeout: "2s", RetryOn: "5xx,always"}}, isErr: true},
+		{r: PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 3, PerTryTimeout: "2s", RetryOn: "999"}}, isErr: true},
+		{r: PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 5000, PerTryTimeout: "2s", RetryOn: "5xx"}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestValidOutlierDetection(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicyOutlierDetection
+		isErr bool
+	}{
+		{r: PlusPolicyOutlierDetection{ConsecutiveErrors: 5, Interval: "10s", EjectionTime: "30s"}, isErr: false},
+		{r: PlusPolicyOutlierDetection{ConsecutiveGatewayErrors: 3, Interval: "10s", EjectionTime: "30s"}, isErr: false},
+		{r: PlusPolicyOutlierDetection{ConsecutiveLocalOriginFailures: 3, SplitExternalLocalOriginErrors: true, Interval: "10s", EjectionTime: "30s"}, isErr: false},
+		{r: PlusPolicyOutlierDetection{ConsecutiveLocalOriginFailures: 3, Interval: "10s", EjectionTime: "30s"}, isErr: true},
+		{r: PlusPolicyOutlierDetection{Interval: "10s", EjectionTime: "30s"}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestFaultInScope(t *testing.T) {
+	blue := &PlusApp{Version: "blue"}
+	green := &PlusApp{Version: "green"}
+
+	fault := &PlusPolicyFault{}
+	require.True(t, fault.InScope(blue, true))
+	require.True(t, fault.InScope(nil, true))
+
+	fault = &PlusPolicyFault{Scope: FaultScopeMesh, Versions: []string{"green"}}
+	require.False(t, fault.InScope(green, true))
+	require.False(t, fault.InScope(blue, false))
+	require.True(t, fault.InScope(green, false))
+
+	fault = &PlusPolicyFault{Scope: FaultScopeGateway, Versions: []string{"green"}}
+	require.False(t, fault.InScope(nil, true))
+	require.True(t, fault.InScope(green, true))
+}
@@ -0,0 +1,177 @@
+package v1
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// 应用协议，和 istio 通过 Service 端口名称前缀选择协议的约定一致
+const (
+	ProtocolHTTP    = "http"
+	ProtocolHTTP2   = "http2"
+	ProtocolHTTPS   = "https"
+	ProtocolGRPC    = "grpc"
+	ProtocolGRPCWeb = "grpc-web"
+	ProtocolTCP     = "tcp"
+	ProtocolTLS     = "tls"
+	ProtocolNone    = "none" //不解析协议，按照 tcp 转发
+)
+
+var supportedProtocols = []string{ProtocolHTTP, ProtocolHTTP2, ProtocolHTTPS, ProtocolGRPC, ProtocolGRPCWeb, ProtocolTCP, ProtocolTLS, ProtocolNone}
+
+// VirtualService 中使用的路由类型
+const (
+	RouteTypeHTTP = "http"
+	RouteTypeTCP  = "tcp"
+	RouteTypeTLS  = "tls"
+)
+
+// GetRouteType 协议对应的路由类型，https 对于 sidecar 和网关是透传的 TLS 流量，按照 SNI 路由
+func (r *PlusApp) GetRouteType() string {
+	switch r.Protocol {
+	case ProtocolTCP, ProtocolNone:
+		return RouteTypeTCP
+	case ProtocolTLS, ProtocolHTTPS:
+		return RouteTypeTLS
+	default:
+		return RouteTypeHTTP
+	}
+}
+
+// GetPortName Service 端口名称，前缀决定 istio 使用的协议
+func (r *PlusApp) GetPortName() string {
+	protocol := r.Protocol
+	if protocol == ProtocolNone {
+		protocol = ProtocolTCP
+	}
+	return fmt.Sprintf("%s-%d", protocol, r.Port)
+}
+
+// GetIstioProtocol ServiceEntry 端口使用的协议名称
+func (r *PlusApp) GetIstioProtocol() string {
+	return istioProtocol(r.Protocol)
+}
+
+func istioProtocol(protocol string) string {
+	switch protocol {
+	case ProtocolTCP, ProtocolNone:
+		return "TCP"
+	case ProtocolGRPCWeb:
+		return "GRPC-Web"
+	default:
+		return strings.ToUpper(protocol)
+	}
+}
+
+// GetRouteType 所有版本共用一个 VirtualService，校验保证所有版本的路由类型相同
+func (r *Plus) GetRouteType() string {
+	if len(r.Spec.Apps) == 0 {
+		return RouteTypeHTTP
+	}
+	return r.Spec.Apps[0].GetRouteType()
+}
+
+// validateProtocols 按权重分流需要所有版本的路由类型相同，tcp/tls 路由不能使用只对 http 路由生效的配置
+func (r *Plus) validateProtocols(fldPath *field.Path) error {
+	routeType := r.GetRouteType()
+	for i, app := range r.Spec.Apps {
+		if app.GetRouteType() != routeType {
+			err := field.Invalid(fldPath.Child("apps").Index(i).Child("protocol"), app.Protocol, fmt.Sprintf("all apps must use %s routes", routeType))
+			return apierrors.NewInvalid(PlusKind, "protocol", field.ErrorList{err})
+		}
+	}
+
+	if routeType == RouteTypeHTTP {
+		return nil
+	}
+	for _, f := range r.getHttpOnlyFields(fldPath) {
+		if f.set {
+			err := field.Forbidden(f.path, fmt.Sprintf("%s is not supported by %s routes", f.name, routeType))
+			return apierrors.NewInvalid(PlusKind, f.name, field.ErrorList{err})
+		}
+	}
+	return nil
+}
+
+type httpOnlyField struct {
+	path *field.Path
+	name string
+	set  bool
+}
+
+// getHttpOnlyFields 只能用于 http 路由的配置，tcp/tls 路由生成 VirtualService 时会被忽略
+func (r *Plus) getHttpOnlyFields(fldPath *field.Path) []httpOnlyField {
+	fields := make([]httpOnlyField, 0)
+	if e := r.Spec.Policy; e != nil {
+		policyPath := fldPath.Child("policy")
+		fields = append(fields,
+			httpOnlyField{policyPath.Child("timeout"), "timeout", isPositiveDuration(e.Timeout)},
+			httpOnlyField{policyPath.Child("retries"), "retries", e.Retries != nil},
+			httpOnlyField{policyPath.Child("fault"), "fault", e.Fault != nil},
+			httpOnlyField{policyPath.Child("rateLimit"), "rateLimit", e.RateLimit != nil},
+		)
+	}
+	for i, app := range r.Spec.Apps {
+		appPath := fldPath.Child("apps").Index(i)
+		fields = append(fields, httpOnlyField{appPath.Child("headers"), "headers", app.Headers != nil})
+		if e := app.Policy; e != nil {
+			fields = append(fields,
+				httpOnlyField{appPath.Child("policy", "timeout"), "timeout", isPositiveDuration(e.Timeout)},
+				httpOnlyField{appPath.Child("policy", "retries"), "retries", e.Retries != nil},
+			)
+		}
+	}
+
+	gateway := r.Spec.Gateway
+	if gateway == nil {
+		return fields
+	}
+	fldPath = fldPath.Child("gateway")
+	fields = append(fields,
+		httpOnlyField{fldPath.Child("delegate"), "delegate", gateway.Delegate},
+		httpOnlyField{fldPath.Child("maintenance", "enabled"), "maintenance", gateway.Maintenance.IsEnabled()},
+		httpOnlyField{fldPath.Child("redirects"), "redirects", len(gateway.Redirects) > 0},
+		httpOnlyField{fldPath.Child("cors"), "cors", gateway.Cors != nil},
+		httpOnlyField{fldPath.Child("headers"), "headers", gateway.Headers != nil},
+		httpOnlyField{fldPath.Child("versionResponseHeader"), "versionResponseHeader", gateway.VersionResponseHeader != ""},
+		httpOnlyField{fldPath.Child("grpc"), "grpc", gateway.Grpc != nil},
+		httpOnlyField{fldPath.Child("access", "jwt"), "jwt", gateway.Access != nil && gateway.Access.Jwt != nil},
+	)
+
+	versions := make([]string, 0, len(gateway.Route))
+	for version := range gateway.Route {
+		versions = append(versions, version)
+	}
+	sort.Strings(versions)
+	for _, version := range versions {
+		if route := gateway.Route[version]; route != nil {
+			routePath := fldPath.Child("route").Key(version)
+			fields = append(fields,
+				httpOnlyField{routePath.Child("headersMatch"), "headersMatch", len(route.HeadersMatch) > 0},
+				httpOnlyField{routePath.Child("headers"), "headers", route.Headers != nil},
+				httpOnlyField{routePath.Child("cors"), "cors", route.Cors != nil},
+			)
+		}
+	}
+
+	for i, listener := range gateway.Listeners {
+		listenerPath := fldPath.Child("listeners").Index(i)
+		fields = append(fields,
+			httpOnlyField{listenerPath.Child("delegate"), "delegate", listener.Delegate},
+			httpOnlyField{listenerPath.Child("rewrite"), "rewrite", listener.Rewrite != ""},
+			httpOnlyField{listenerPath.Child("cors"), "cors", listener.Cors != nil},
+		)
+	}
+	return fields
+}
+
+// isPositiveDuration spec.policy.timeout 是必填的，tcp/tls 路由只能设置为 0s
+func isPositiveDuration(value string) bool {
+	d, err := time.ParseDuration(value)
+	return err == nil && d > 0
+}
@@ -0,0 +1,69 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func TestValidProtocols(t *testing.T) {
+	app := func(version, protocol string) *PlusApp {
+		return &PlusApp{Version: version, MinReplicas: 1, MaxReplicas: 1, Port: 6379, Protocol: protocol}
+	}
+	plus := func(gateway *PlusGateway, apps ...*PlusApp) Plus {
+		return Plus{ObjectMeta: metav1.ObjectMeta{Name: "redis"}, Spec: PlusSpec{Gateway: gateway, Apps: apps}}
+	}
+	withPolicy := func(plus Plus, policy *PlusPolicy) Plus {
+		plus.Spec.Policy = policy
+		return plus
+	}
+	gateway := &PlusGateway{Hosts: []string{"redis.a.com"}}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: plus(nil, app("blue", ProtocolTCP), app("green", ProtocolNone)), isErr: false},
+		{r: plus(gateway, app("blue", ProtocolTLS), app("green", ProtocolHTTPS)), isErr: false},
+		{r: plus(gateway, app("blue", ProtocolGRPCWeb), app("green", ProtocolHTTP2)), isErr: false},
+		{r: plus(nil, app("blue", "udp")), isErr: true},
+		{r: plus(nil, app("blue", ProtocolHTTP), app("green", ProtocolTCP)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Delegate: true}, app("blue", ProtocolTCP)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Maintenance: &PlusGatewayMaintenance{Enabled: true}}, app("blue", ProtocolTLS)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Redirects: []*PlusGatewayRedirect{{From: "/old", To: "/new"}}}, app("blue", ProtocolTCP)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Cors: &PlusGatewayCors{AllowOrigins: []string{"https://a.com"}}}, app("blue", ProtocolTLS)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Access: &PlusGatewayAccess{Jwt: &PlusGatewayJwt{Issuer: "https://auth"}}}, app("blue", ProtocolTCP)), isErr: true},
+		{r: plus(&PlusGateway{Hosts: []string{"redis.a.com"}, Access: &PlusGatewayAccess{DenyIPs: []string{"1.1.1.1"}}}, app("blue", ProtocolTCP)), isErr: false},
+		{r: withPolicy(plus(nil, app("blue", ProtocolTCP)), &PlusPolicy{Timeout: "10s"}), isErr: true},
+		{r: withPolicy(plus(nil, app("blue", ProtocolTCP)), &PlusPolicy{Timeout: "0s", Fault: &PlusPolicyFault{}}), isErr: true},
+		{r: withPolicy(plus(nil, app("blue", ProtocolTLS)), &PlusPolicy{Timeout: "0s", RateLimit: &PlusPolicyRateLimit{}}), isErr: true},
+		{r: withPolicy(plus(nil, app("blue", ProtocolTCP)), &PlusPolicy{Timeout: "0s", MaxRequest: 100}), isErr: false},
+	}
+	for _, test := range tests {
+		err := test.r.Validate()
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestProtocolRouteType(t *testing.T) {
+	tests := []struct {
+		protocol  string
+		routeType string
+		portName  string
+	}{
+		{protocol: ProtocolHTTP, routeType: RouteTypeHTTP, portName: "http-8080"},
+		{protocol: ProtocolGRPCWeb, routeType: RouteTypeHTTP, portName: "grpc-web-8080"},
+		{protocol: ProtocolTCP, routeType: RouteTypeTCP, portName: "tcp-8080"},
+		{protocol: ProtocolNone, routeType: RouteTypeTCP, portName: "tcp-8080"},
+		{protocol: ProtocolTLS, routeType: RouteTypeTLS, portName: "tls-8080"},
+		{protocol: ProtocolHTTPS, routeType: RouteTypeTLS, portName: "https-8080"},
+	}
+	for _, test := range tests {
+		app := &PlusApp{Port: 8080, Protocol: test.protocol}
+		require.Equal(t, test.routeType, app.GetRouteType())
+		require.Equal(t, test.portName, app.GetPortName())
+	}
+}
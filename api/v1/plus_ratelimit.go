@@ -0,0 +1,255 @@
+package v1
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+// RateLimitDescriptorKey 规则命中时生成的描述符的 key，和 envoy header_value_match 的默认值一致
+const RateLimitDescriptorKey = "header_match"
+
+// DefaultRateLimitServicePort envoyproxy/ratelimit 默认的 grpc 端口
+const DefaultRateLimitServicePort = 8081
+
+// httpMethods 限流规则可以匹配的请求方法，和 :method 伪头精确匹配，所以必须是大写
+var httpMethods = []string{
+	http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch,
+	http.MethodDelete, http.MethodConnect, http.MethodOptions, http.MethodTrace,
+}
+
+// PlusPolicyRateLimit 接口级别限流。
+// local 在每个实例的 sidecar 中单独计数，global 由外部限流服务统一计数
+type PlusPolicyRateLimit struct {
+	Local  []*PlusRateLimitRule `json:"local,omitempty"`
+	Global *PlusGlobalRateLimit `json:"global,omitempty"`
+}
+
+// PlusGlobalRateLimit 全局限流，描述符配置写入 <name>-ratelimit ConfigMap，由限流服务加载
+type PlusGlobalRateLimit struct {
+	Service         string               `json:"service,omitempty"`         //限流服务地址，如 ratelimit.ratelimit.svc.cluster.local
+	Port            uint32               `json:"port,omitempty"`            //限流服务 grpc 端口，默认 8081
+	Domain          string               `json:"domain,omitempty"`          //限流服务中的 domain，默认 <namespace>-<name>
+	Timeout         string               `json:"timeout,omitempty"`         //调用限流服务的超时时间
+	FailureModeDeny bool                 `json:"failureModeDeny,omitempty"` //限流服务不可用时是否拒绝请求
+	Rules           []*PlusRateLimitRule `json:"rules,omitempty"`
+}
+
+// PlusRateLimitRule 一条令牌桶限流规则，path、method、headers 同时满足时命中
+type PlusRateLimitRule struct {
+	Name     string            `json:"name"`               //规则名称，作为描述符的值
+	Path     string            `json:"path,omitempty"`     //路径前缀，应用收到的路径，默认 /
+	Method   string            `json:"method,omitempty"`   //为空时对所有方法生效
+	Headers  map[string]string `json:"headers,omitempty"`  //请求头精确匹配
+	Requests uint32            `json:"requests"`           //每个周期允许的请求数
+	Interval string            `json:"interval,omitempty"` //周期，默认 1s。全局限流只支持 1s,1m,1h,24h
+	Burst    uint32            `json:"burst,omitempty"`    //本地限流令牌桶的容量，默认等于 requests
+}
+
+// RateLimitServiceConfig envoyproxy/ratelimit 的配置文件格式
+type RateLimitServiceConfig struct {
+	Domain      string                `json:"domain"`
+	Descriptors []RateLimitDescriptor `json:"descriptors"`
+}
+
+type RateLimitDescriptor struct {
+	Key       string                `json:"key"`
+	Value     string                `json:"value,omitempty"`
+	RateLimit *RateLimitServiceUnit `json:"rate_limit,omitempty"`
+}
+
+type RateLimitServiceUnit struct {
+	Unit            string `json:"unit"`
+	RequestsPerUnit uint32 `json:"requests_per_unit"`
+}
+
+var rateLimitUnits = map[time.Duration]string{
+	time.Second:    "second",
+	time.Minute:    "minute",
+	time.Hour:      "hour",
+	time.Hour * 24: "day",
+}
+
+func (r *PlusRateLimitRule) GetPath() string {
+	if r.Path == "" {
+		return "/"
+	}
+	return r.Path
+}
+
+func (r *PlusRateLimitRule) GetInterval() time.Duration {
+	if r.Interval == "" {
+		return time.Second
+	}
+	d, err := time.ParseDuration(r.Interval)
+	if err != nil {
+		return time.Second
+	}
+	return d
+}
+
+func (r *PlusRateLimitRule) GetBurst() uint32 {
+	if r.Burst == 0 {
+		return r.Requests
+	}
+	return r.Burst
+}
+
+// GetUnit 全局限流的周期单位
+func (r *PlusRateLimitRule) GetUnit() string {
+	return rateLimitUnits[r.GetInterval()]
+}
+
+func (r *PlusGlobalRateLimit) GetPort() uint32 {
+	if r.Port == 0 {
+		return DefaultRateLimitServicePort
+	}
+	return r.Port
+}
+
+// GetClusterName 限流服务在 sidecar 中的 cluster 名称
+func (r *PlusGlobalRateLimit) GetClusterName() string {
+	return fmt.Sprintf("outbound|%d||%s", r.GetPort(), r.Service)
+}
+
+// GetSidecarHost 限流服务在 Sidecar 中的 host，sidecar 限制出口时也需要下发限流服务的 cluster
+func (r *PlusGlobalRateLimit) GetSidecarHost() string {
+	return "*/" + r.Service
+}
+
+func (r *PlusGlobalRateLimit) GetDomain(plus *Plus) string {
+	if r.Domain == "" {
+		return fmt.Sprintf("%s-%s", plus.GetNamespace(), plus.GetName())
+	}
+	return r.Domain
+}
+
+// GenerateRateLimitConfig 生成外部限流服务的描述符配置，没有配置全局限流时返回 nil
+func (r *Plus) GenerateRateLimitConfig() *RateLimitServiceConfig {
+	if r.Spec.Policy == nil || r.Spec.Policy.RateLimit == nil || r.Spec.Policy.RateLimit.Global == nil {
+		return nil
+	}
+	global := r.Spec.Policy.RateLimit.Global
+	config := &RateLimitServiceConfig{
+		Domain:      global.GetDomain(r),
+		Descriptors: make([]RateLimitDescriptor, 0, len(global.Rules)),
+	}
+	for _, rule := range global.Rules {
+		config.Descriptors = append(config.Descriptors, RateLimitDescriptor{
+			Key:   RateLimitDescriptorKey,
+			Value: rule.Name,
+			RateLimit: &RateLimitServiceUnit{
+				Unit:            rule.GetUnit(),
+				RequestsPerUnit: rule.Requests,
+			},
+		})
+	}
+	return config
+}
+
+func (r *PlusPolicyRateLimit) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("rateLimit")
+
+	if err := validateRateLimitRules(fldPath.Child("local"), r.Local, false); err != nil {
+		return err
+	}
+
+	if e := r.Global; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *PlusGlobalRateLimit) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("global")
+
+	if r.Service == "" {
+		err := field.Invalid(fldPath.Child("service"), r.Service, "service can't be empty")
+		return apierrors.NewInvalid(PlusKind, "service", field.ErrorList{err})
+	}
+
+	if err := validateOptionalDuration(fldPath.Child("timeout"), "timeout", r.Timeout); err != nil {
+		return err
+	}
+
+	return validateRateLimitRules(fldPath.Child("rules"), r.Rules, true)
+}
+
+func validateRateLimitRules(fldPath *field.Path, rules []*PlusRateLimitRule, global bool) error {
+	names := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		if err := rule.Validate(fldPath.Index(i), global); err != nil {
+			return err
+		}
+		if names[rule.Name] {
+			err := field.Duplicate(fldPath.Index(i).Child("name"), rule.Name)
+			return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+		}
+		names[rule.Name] = true
+	}
+	return nil
+}
+
+func (r *PlusRateLimitRule) Validate(fldPath *field.Path, global bool) error {
+	if r.Name == "" {
+		err := field.Invalid(fldPath.Child("name"), r.Name, "name can't be empty")
+		return apierrors.NewInvalid(PlusKind, "name", field.ErrorList{err})
+	}
+
+	if r.Requests == 0 {
+		err := field.Invalid(fldPath.Child("requests"), r.Requests, "requests must > 0")
+		return apierrors.NewInvalid(PlusKind, "requests", field.ErrorList{err})
+	}
+
+	if r.Interval != "" {
+		d, err := time.ParseDuration(r.Interval)
+		if err != nil {
+			err := field.Invalid(fldPath.Child("interval"), r.Interval, err.Error())
+			return apierrors.NewInvalid(PlusKind, "interval", field.ErrorList{err})
+		}
+		// envoy 要求令牌桶的填充周期不小于 50ms
+		if d < 50*time.Millisecond {
+			err := field.Invalid(fldPath.Child("interval"), r.Interval, "interval must >= 50ms")
+			return apierrors.NewInvalid(PlusKind, "interval", field.ErrorList{err})
+		}
+	}
+
+	if global {
+		if r.GetUnit() == "" {
+			err := field.NotSupported(fldPath.Child("interval"), r.Interval, []string{"1s", "1m", "1h", "24h"})
+			return apierrors.NewInvalid(PlusKind, "interval", field.ErrorList{err})
+		}
+		if r.Burst != 0 {
+			err := field.Invalid(fldPath.Child("burst"), r.Burst, "burst is only supported by local rate limit")
+			return apierrors.NewInvalid(PlusKind, "burst", field.ErrorList{err})
+		}
+	} else if r.Burst != 0 && r.Burst < r.Requests {
+		err := field.Invalid(fldPath.Child("burst"), r.Burst, "burst must >= requests")
+		return apierrors.NewInvalid(PlusKind, "burst", field.ErrorList{err})
+	}
+
+	if r.Path != "" && r.Path[0] != '/' {
+		err := field.Invalid(fldPath.Child("path"), r.Path, "path must start with /")
+		return apierrors.NewInvalid(PlusKind, "path", field.ErrorList{err})
+	}
+
+	if r.Method != "" && !containsString(httpMethods, r.Method) {
+		err := field.NotSupported(fldPath.Child("method"), r.Method, httpMethods)
+		return apierrors.NewInvalid(PlusKind, "method", field.ErrorList{err})
+	}
+
+	for name := range r.Headers {
+		if errs := validation.IsHTTPHeaderName(name); len(errs) != 0 {
+			err := field.Invalid(fldPath.Child("headers").Key(name), name, strings.Join(errs, ","))
+			return apierrors.NewInvalid(PlusKind, "headers", field.ErrorList{err})
+		}
+	}
+	return nil
+}
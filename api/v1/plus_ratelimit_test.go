@@ -0,0 +1,92 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/yaml"
+	"testing"
+)
+
+func TestValidRateLimit(t *testing.T) {
+	tests := []struct {
+		r     PlusPolicyRateLimit
+		isErr bool
+	}{
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "login", Path: "/login", Method: "POST", Requests: 10, Interval: "1m", Burst: 20}}}, isErr: false},
+		{r: PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{Service: "ratelimit.ratelimit.svc.cluster.local", Timeout: "100ms",
+			Rules: []*PlusRateLimitRule{{Name: "api", Path: "/api", Headers: map[string]string{"x-user": "test"}, Requests: 100, Interval: "1h"}}}}, isErr: false},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "login", Requests: 0}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Requests: 10}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10}, {Name: "a", Requests: 10}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Interval: "10ms"}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Burst: 5}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Path: "api"}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Method: "post"}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Method: "FETCH"}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Local: []*PlusRateLimitRule{{Name: "a", Requests: 10, Headers: map[string]string{"x user": "test"}}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{Rules: []*PlusRateLimitRule{{Name: "a", Requests: 10}}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{Service: "ratelimit", Rules: []*PlusRateLimitRule{{Name: "a", Requests: 10, Interval: "10s"}}}}, isErr: true},
+		{r: PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{Service: "ratelimit", Rules: []*PlusRateLimitRule{{Name: "a", Requests: 10, Burst: 20}}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+// rateLimitStandIn 按照 envoyproxy/ratelimit 的语义加载描述符配置，同一个周期内按描述符计数
+type rateLimitStandIn struct {
+	config RateLimitServiceConfig
+	hits   map[string]uint32
+}
+
+func (s *rateLimitStandIn) shouldRateLimit(domain, key, value string) bool {
+	if domain != s.config.Domain {
+		return false
+	}
+	for _, d := range s.config.Descriptors {
+		if d.Key != key || d.Value != value || d.RateLimit == nil {
+			continue
+		}
+		s.hits[value]++
+		return s.hits[value] > d.RateLimit.RequestsPerUnit
+	}
+	return false
+}
+
+func TestGenerateRateLimitConfig(t *testing.T) {
+	plus := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "default"},
+		Spec:       PlusSpec{Policy: &PlusPolicy{}},
+	}
+	require.Nil(t, plus.GenerateRateLimitConfig())
+
+	plus.Spec.Policy.RateLimit = &PlusPolicyRateLimit{Global: &PlusGlobalRateLimit{
+		Service: "ratelimit.ratelimit.svc.cluster.local",
+		Rules: []*PlusRateLimitRule{
+			{Name: "login", Path: "/login", Requests: 2},
+			{Name: "api", Path: "/api", Requests: 100, Interval: "24h"},
+		},
+	}}
+	require.Equal(t, "outbound|8081||ratelimit.ratelimit.svc.cluster.local", plus.Spec.Policy.RateLimit.Global.GetClusterName())
+
+	data, err := yaml.Marshal(plus.GenerateRateLimitConfig())
+	require.Nil(t, err)
+
+	standIn := &rateLimitStandIn{hits: map[string]uint32{}}
+	require.Nil(t, yaml.Unmarshal(data, &standIn.config))
+	require.Equal(t, "default-svc-a", standIn.config.Domain)
+	require.Equal(t, "second", standIn.config.Descriptors[0].RateLimit.Unit)
+	require.Equal(t, "day", standIn.config.Descriptors[1].RateLimit.Unit)
+
+	require.False(t, standIn.shouldRateLimit("default-svc-a", RateLimitDescriptorKey, "login"))
+	require.False(t, standIn.shouldRateLimit("default-svc-a", RateLimitDescriptorKey, "login"))
+	require.True(t, standIn.shouldRateLimit("default-svc-a", RateLimitDescriptorKey, "login"))
+	require.False(t, standIn.shouldRateLimit("default-svc-a", RateLimitDescriptorKey, "api"))
+	require.False(t, standIn.shouldRateLimit("default-svc-b", RateLimitDescriptorKey, "login"))
+}
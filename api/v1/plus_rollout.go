@@ -0,0 +1,135 @@
+package v1
+
+import (
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	// ConditionRolloutStuck 有版本的 Deployment 超过 progressDeadlineSeconds 没有完成更新
+	ConditionRolloutStuck = "RolloutStuck"
+	// ReasonProgressDeadlineExceeded 和 Deployment Progressing condition 的 reason 一致
+	ReasonProgressDeadlineExceeded = "ProgressDeadlineExceeded"
+
+	DefaultProgressDeadlineSeconds = 600
+	DefaultRevisionHistoryLimit    = 10
+)
+
+// PlusAppRollout 滚动更新的参数，没有设置的使用 k8s 的默认值
+type PlusAppRollout struct {
+	MaxSurge                *intstr.IntOrString `json:"maxSurge,omitempty"`                //默认 25%
+	MaxUnavailable          *intstr.IntOrString `json:"maxUnavailable,omitempty"`          //默认 25%，0 时只有新 Pod Ready 后才会删除旧 Pod
+	MinReadySeconds         int32               `json:"minReadySeconds,omitempty"`         //新 Pod Ready 后持续多久才算可用
+	ProgressDeadlineSeconds int32               `json:"progressDeadlineSeconds,omitempty"` //默认 600，超时后 Plus 会有 RolloutStuck condition
+	RevisionHistoryLimit    *int32              `json:"revisionHistoryLimit,omitempty"`    //默认 10
+}
+
+func (r *PlusAppRollout) GetMaxSurge() *intstr.IntOrString {
+	if r == nil || r.MaxSurge == nil {
+		maxSurge := intstr.FromString("25%")
+		return &maxSurge
+	}
+	return r.MaxSurge
+}
+
+func (r *PlusAppRollout) GetMaxUnavailable() *intstr.IntOrString {
+	if r == nil || r.MaxUnavailable == nil {
+		maxUnavailable := intstr.FromString("25%")
+		return &maxUnavailable
+	}
+	return r.MaxUnavailable
+}
+
+func (r *PlusAppRollout) GetMinReadySeconds() int32 {
+	if r == nil {
+		return 0
+	}
+	return r.MinReadySeconds
+}
+
+func (r *PlusAppRollout) GetProgressDeadlineSeconds() *int32 {
+	progressDeadlineSeconds := int32(DefaultProgressDeadlineSeconds)
+	if r != nil && r.ProgressDeadlineSeconds > 0 {
+		progressDeadlineSeconds = r.ProgressDeadlineSeconds
+	}
+	return &progressDeadlineSeconds
+}
+
+func (r *PlusAppRollout) GetRevisionHistoryLimit() *int32 {
+	if r == nil || r.RevisionHistoryLimit == nil {
+		revisionHistoryLimit := int32(DefaultRevisionHistoryLimit)
+		return &revisionHistoryLimit
+	}
+	return r.RevisionHistoryLimit
+}
+
+// IsProgressDeadlineExceeded Deployment 是否更新超时，返回 condition 的 message
+func IsProgressDeadlineExceeded(deployment *appsv1.Deployment) (bool, string) {
+	for _, condition := range deployment.Status.Conditions {
+		if condition.Type == appsv1.DeploymentProgressing &&
+			condition.Status == corev1.ConditionFalse &&
+			condition.Reason == ReasonProgressDeadlineExceeded {
+			return true, condition.Message
+		}
+	}
+	return false, ""
+}
+
+// Validate 和 k8s 校验 Deployment 的规则一致，提前在 webhook 中拒绝
+func (r *PlusAppRollout) Validate(fldPath *field.Path, strategyType appsv1.DeploymentStrategyType) error {
+	fldPath = fldPath.Child("rollout")
+
+	if strategyType == appsv1.RecreateDeploymentStrategyType && (r.MaxSurge != nil || r.MaxUnavailable != nil) {
+		err := field.Invalid(fldPath, r, "maxSurge and maxUnavailable are not supported by Recreate")
+		return apierrors.NewInvalid(PlusKind, "rollout", field.ErrorList{err})
+	}
+
+	maxSurge, err := validateIntOrPercent(fldPath.Child("maxSurge"), "maxSurge", r.MaxSurge)
+	if err != nil {
+		return err
+	}
+	maxUnavailable, err := validateIntOrPercent(fldPath.Child("maxUnavailable"), "maxUnavailable", r.MaxUnavailable)
+	if err != nil {
+		return err
+	}
+	if r.MaxSurge != nil && r.MaxUnavailable != nil && maxSurge == 0 && maxUnavailable == 0 {
+		err := field.Invalid(fldPath.Child("maxUnavailable"), r.MaxUnavailable, "maxSurge and maxUnavailable can't both be 0")
+		return apierrors.NewInvalid(PlusKind, "maxUnavailable", field.ErrorList{err})
+	}
+
+	if r.MinReadySeconds < 0 {
+		err := field.Invalid(fldPath.Child("minReadySeconds"), r.MinReadySeconds, "minReadySeconds must >= 0")
+		return apierrors.NewInvalid(PlusKind, "minReadySeconds", field.ErrorList{err})
+	}
+
+	if r.ProgressDeadlineSeconds < 0 || *r.GetProgressDeadlineSeconds() <= r.MinReadySeconds {
+		err := field.Invalid(fldPath.Child("progressDeadlineSeconds"), r.ProgressDeadlineSeconds, "progressDeadlineSeconds must > minReadySeconds")
+		return apierrors.NewInvalid(PlusKind, "progressDeadlineSeconds", field.ErrorList{err})
+	}
+
+	if r.RevisionHistoryLimit != nil && *r.RevisionHistoryLimit < 0 {
+		err := field.Invalid(fldPath.Child("revisionHistoryLimit"), *r.RevisionHistoryLimit, "revisionHistoryLimit must >= 0")
+		return apierrors.NewInvalid(PlusKind, "revisionHistoryLimit", field.ErrorList{err})
+	}
+	return nil
+}
+
+// validateIntOrPercent 整数不能小于 0，百分比在 0% 到 100% 之间，返回整数或者百分比的值
+func validateIntOrPercent(fldPath *field.Path, name string, value *intstr.IntOrString) (int, error) {
+	if value == nil {
+		return 0, nil
+	}
+	v, err := intstr.GetScaledValueFromIntOrPercent(value, 100, false)
+	if err != nil {
+		err := field.Invalid(fldPath, value.String(), err.Error())
+		return 0, apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+	}
+	if v < 0 || (value.Type == intstr.String && v > 100) {
+		err := field.Invalid(fldPath, value.String(), "must be >= 0 and percent must <= 100%")
+		return 0, apierrors.NewInvalid(PlusKind, name, field.ErrorList{err})
+	}
+	return v, nil
+}
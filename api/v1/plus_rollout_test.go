@@ -0,0 +1,83 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidRollout(t *testing.T) {
+	intOrString := func(value intstr.IntOrString) *intstr.IntOrString {
+		return &value
+	}
+	int32Ptr := func(value int32) *int32 {
+		return &value
+	}
+	tests := []struct {
+		r            PlusAppRollout
+		strategyType appsv1.DeploymentStrategyType
+		isErr        bool
+	}{
+		{r: PlusAppRollout{}, isErr: false},
+		{r: PlusAppRollout{MaxSurge: intOrString(intstr.FromInt(1)), MaxUnavailable: intOrString(intstr.FromInt(0)), MinReadySeconds: 10}, isErr: false},
+		{r: PlusAppRollout{MaxSurge: intOrString(intstr.FromString("50%")), ProgressDeadlineSeconds: 120, RevisionHistoryLimit: int32Ptr(3)}, isErr: false},
+		{r: PlusAppRollout{MinReadySeconds: 30}, strategyType: appsv1.RecreateDeploymentStrategyType, isErr: false},
+		{r: PlusAppRollout{MaxUnavailable: intOrString(intstr.FromInt(0))}, strategyType: appsv1.RecreateDeploymentStrategyType, isErr: true},
+		{r: PlusAppRollout{MaxSurge: intOrString(intstr.FromString("0%")), MaxUnavailable: intOrString(intstr.FromInt(0))}, isErr: true},
+		{r: PlusAppRollout{MaxSurge: intOrString(intstr.FromString("150%"))}, isErr: true},
+		{r: PlusAppRollout{MaxSurge: intOrString(intstr.FromString("abc"))}, isErr: true},
+		{r: PlusAppRollout{MaxUnavailable: intOrString(intstr.FromInt(-1))}, isErr: true},
+		{r: PlusAppRollout{MinReadySeconds: -1}, isErr: true},
+		{r: PlusAppRollout{MinReadySeconds: 60, ProgressDeadlineSeconds: 60}, isErr: true},
+		{r: PlusAppRollout{MinReadySeconds: 600}, isErr: true},
+		{r: PlusAppRollout{RevisionHistoryLimit: int32Ptr(-1)}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"), test.strategyType)
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestRolloutDefaults(t *testing.T) {
+	var rollout *PlusAppRollout
+	require.Equal(t, "25%", rollout.GetMaxSurge().String())
+	require.Equal(t, "25%", rollout.GetMaxUnavailable().String())
+	require.Equal(t, int32(0), rollout.GetMinReadySeconds())
+	require.Equal(t, int32(DefaultProgressDeadlineSeconds), *rollout.GetProgressDeadlineSeconds())
+	require.Equal(t, int32(DefaultRevisionHistoryLimit), *rollout.GetRevisionHistoryLimit())
+
+	maxUnavailable := intstr.FromInt(0)
+	revisionHistoryLimit := int32(0)
+	rollout = &PlusAppRollout{MaxUnavailable: &maxUnavailable, ProgressDeadlineSeconds: 120, RevisionHistoryLimit: &revisionHistoryLimit}
+	require.Equal(t, "25%", rollout.GetMaxSurge().String())
+	require.Equal(t, "0", rollout.GetMaxUnavailable().String())
+	require.Equal(t, int32(120), *rollout.GetProgressDeadlineSeconds())
+	require.Equal(t, int32(0), *rollout.GetRevisionHistoryLimit())
+}
+
+func TestIsProgressDeadlineExceeded(t *testing.T) {
+	deployment := &appsv1.Deployment{}
+	exceeded, _ := IsProgressDeadlineExceeded(deployment)
+	require.False(t, exceeded)
+
+	deployment.Status.Conditions = []appsv1.DeploymentCondition{{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionTrue, Reason: "NewReplicaSetAvailable",
+	}}
+	exceeded, _ = IsProgressDeadlineExceeded(deployment)
+	require.False(t, exceeded)
+
+	deployment.Status.Conditions[0] = appsv1.DeploymentCondition{
+		Type: appsv1.DeploymentProgressing, Status: corev1.ConditionFalse, Reason: ReasonProgressDeadlineExceeded,
+		Message: `ReplicaSet "svc-a-blue-5d9c" has timed out progressing.`,
+	}
+	exceeded, message := IsProgressDeadlineExceeded(deployment)
+	require.True(t, exceeded)
+	require.Equal(t, `ReplicaSet "svc-a-blue-5d9c" has timed out progressing.`, message)
+}
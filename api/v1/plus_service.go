@@ -0,0 +1,108 @@
+package v1
+
+import (
+	"fmt"
+	"net"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	ServiceTypeClusterIP    = "ClusterIP"
+	ServiceTypeNodePort     = "NodePort"
+	ServiceTypeLoadBalancer = "LoadBalancer"
+	// ServiceTypeHeadless 不分配 ClusterIP，DNS 直接解析到 Pod
+	ServiceTypeHeadless = "Headless"
+)
+
+var serviceTypes = []string{ServiceTypeClusterIP, ServiceTypeNodePort, ServiceTypeLoadBalancer, ServiceTypeHeadless}
+
+// PlusService <name> Service 的配置，perVersion 时为每个版本额外创建 <name>-<version> Service
+type PlusService struct {
+	Type                     string            `json:"type,omitempty"`                     //ClusterIP(默认), NodePort, LoadBalancer, Headless
+	Annotations              map[string]string `json:"annotations,omitempty"`              //如云厂商负载均衡的配置
+	ExternalTrafficPolicy    string            `json:"externalTrafficPolicy,omitempty"`    //Cluster(默认) 或 Local，NodePort 和 LoadBalancer 有效
+	LoadBalancerSourceRanges []string          `json:"loadBalancerSourceRanges,omitempty"` //允许访问负载均衡的来源网段
+	PerVersion               bool              `json:"perVersion,omitempty"`               //为每个版本创建 <name>-<version> Service，类型为 ClusterIP，headless 时同样为 headless
+	// Aliases 网格内通过 <name>-<version>.<namespace>.svc.cluster.local 固定访问某个版本，不受权重影响。
+	// 开启 perVersion 时由版本的 Service 解析域名，否则创建 ServiceEntry，需要开启 istio 的 DNS 代理
+	Aliases bool `json:"aliases,omitempty"`
+}
+
+// GetType <name> Service 的类型，headless 也是 ClusterIP 类型
+func (r *PlusService) GetType() corev1.ServiceType {
+	if r == nil || r.Type == "" || r.Type == ServiceTypeHeadless {
+		return corev1.ServiceTypeClusterIP
+	}
+	return corev1.ServiceType(r.Type)
+}
+
+func (r *PlusService) IsHeadless() bool {
+	return r != nil && r.Type == ServiceTypeHeadless
+}
+
+func (r *PlusService) IsPerVersion() bool {
+	return r != nil && r.PerVersion
+}
+
+func (r *PlusService) IsAliases() bool {
+	return r != nil && r.Aliases
+}
+
+// GetVersionServiceName 版本的 Service 名称，和 Deployment 同名
+func (r *Plus) GetVersionServiceName(app *PlusApp) string {
+	return r.GetAppName(app)
+}
+
+// GetVersionHost 版本的别名域名
+func (r *Plus) GetVersionHost(app *PlusApp) string {
+	return fmt.Sprintf("%s.%s.svc.cluster.local", r.GetVersionServiceName(app), r.GetNamespace())
+}
+
+// GetVersionAliases 版本和别名域名的对应关系，没有开启 aliases 时为 nil
+func (r *Plus) GetVersionAliases() map[string]string {
+	if !r.Spec.Service.IsAliases() || len(r.Spec.Apps) == 0 {
+		return nil
+	}
+	aliases := make(map[string]string, len(r.Spec.Apps))
+	for _, app := range r.Spec.Apps {
+		aliases[app.Version] = r.GetVersionHost(app)
+	}
+	return aliases
+}
+
+func (r *PlusService) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("service")
+
+	if r.Type != "" && !containsString(serviceTypes, r.Type) {
+		err := field.NotSupported(fldPath.Child("type"), r.Type, serviceTypes)
+		return apierrors.NewInvalid(PlusKind, "type", field.ErrorList{err})
+	}
+
+	external := r.Type == ServiceTypeNodePort || r.Type == ServiceTypeLoadBalancer
+	if r.ExternalTrafficPolicy != "" {
+		if !external {
+			err := field.Invalid(fldPath.Child("externalTrafficPolicy"), r.ExternalTrafficPolicy, "externalTrafficPolicy is only supported by NodePort and LoadBalancer")
+			return apierrors.NewInvalid(PlusKind, "externalTrafficPolicy", field.ErrorList{err})
+		}
+		policies := []string{string(corev1.ServiceExternalTrafficPolicyTypeCluster), string(corev1.ServiceExternalTrafficPolicyTypeLocal)}
+		if !containsString(policies, r.ExternalTrafficPolicy) {
+			err := field.NotSupported(fldPath.Child("externalTrafficPolicy"), r.ExternalTrafficPolicy, policies)
+			return apierrors.NewInvalid(PlusKind, "externalTrafficPolicy", field.ErrorList{err})
+		}
+	}
+
+	if len(r.LoadBalancerSourceRanges) > 0 && r.Type != ServiceTypeLoadBalancer {
+		err := field.Invalid(fldPath.Child("loadBalancerSourceRanges"), r.LoadBalancerSourceRanges, "loadBalancerSourceRanges is only supported by LoadBalancer")
+		return apierrors.NewInvalid(PlusKind, "loadBalancerSourceRanges", field.ErrorList{err})
+	}
+	for i, cidr := range r.LoadBalancerSourceRanges {
+		if _, _, err := net.ParseCIDR(cidr); err != nil {
+			err := field.Invalid(fldPath.Child("loadBalancerSourceRanges").Index(i), cidr, err.Error())
+			return apierrors.NewInvalid(PlusKind, "loadBalancerSourceRanges", field.ErrorList{err})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidService(t *testing.T) {
+	tests := []struct {
+		r     PlusService
+		isErr bool
+	}{
+		{r: PlusService{}, isErr: false},
+		{r: PlusService{Type: ServiceTypeHeadless, PerVersion: true}, isErr: false},
+		{r: PlusService{Type: ServiceTypeLoadBalancer, ExternalTrafficPolicy: "Local", LoadBalancerSourceRanges: []string{"10.0.0.0/8"},
+			Annotations: map[string]string{"service.beta.kubernetes.io/aws-load-balancer-internal": "true"}}, isErr: false},
+		{r: PlusService{Type: ServiceTypeNodePort, ExternalTrafficPolicy: "Cluster"}, isErr: false},
+		{r: PlusService{Type: "ExternalName"}, isErr: true},
+		{r: PlusService{ExternalTrafficPolicy: "Local"}, isErr: true},
+		{r: PlusService{Type: ServiceTypeNodePort, ExternalTrafficPolicy: "local"}, isErr: true},
+		{r: PlusService{Type: ServiceTypeNodePort, LoadBalancerSourceRanges: []string{"10.0.0.0/8"}}, isErr: true},
+		{r: PlusService{Type: ServiceTypeLoadBalancer, LoadBalancerSourceRanges: []string{"10.0.0.1"}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestServiceType(t *testing.T) {
+	var service *PlusService
+	require.Equal(t, corev1.ServiceTypeClusterIP, service.GetType())
+	require.False(t, service.IsHeadless())
+	require.False(t, service.IsPerVersion())
+
+	service = &PlusService{Type: ServiceTypeHeadless, PerVersion: true}
+	require.Equal(t, corev1.ServiceTypeClusterIP, service.GetType())
+	require.True(t, service.IsHeadless())
+	require.True(t, service.IsPerVersion())
+
+	service = &PlusService{Type: ServiceTypeLoadBalancer}
+	require.Equal(t, corev1.ServiceTypeLoadBalancer, service.GetType())
+}
+
+func TestVersionAliases(t *testing.T) {
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "ns"}, Spec: PlusSpec{
+		Apps: []*PlusApp{{Version: "blue", Port: 8080, Protocol: ProtocolGRPCWeb}, {Version: "green", Port: 8080, Protocol: ProtocolNone}},
+	}}
+	require.Nil(t, plus.GetVersionAliases())
+
+	plus.Spec.Service = &PlusService{Aliases: true}
+	require.Equal(t, map[string]string{
+		"blue":  "svc-a-blue.ns.svc.cluster.local",
+		"green": "svc-a-green.ns.svc.cluster.local",
+	}, plus.GetVersionAliases())
+	require.Equal(t, "GRPC-Web", plus.Spec.Apps[0].GetIstioProtocol())
+	require.Equal(t, "TCP", plus.Spec.Apps[1].GetIstioProtocol())
+
+	plus.GenerateStatusDesc()
+	require.Equal(t, plus.GetVersionAliases(), plus.Status.Aliases)
+}
@@ -0,0 +1,112 @@
+package v1
+
+import (
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	SpreadTopologyZone = "zone"
+	SpreadTopologyNode = "node"
+
+	SpreadModeSoft = "soft"
+	SpreadModeHard = "hard"
+)
+
+var spreadTopologies = []string{SpreadTopologyZone, SpreadTopologyNode}
+var spreadModes = []string{SpreadModeSoft, SpreadModeHard}
+
+// PlusAppSpread 版本的 Pod 分散部署的预设，按照 GenerateAppLabels 选择同版本的 Pod，
+// 生成 topologySpreadConstraints 和 podAntiAffinity，和 affinity 中的配置合并
+type PlusAppSpread struct {
+	Topology string `json:"topology,omitempty"` //zone(默认) 或 node
+	Mode     string `json:"mode,omitempty"`     //soft(默认) 尽量分散，hard 无法分散时不调度
+	MaxSkew  int32  `json:"maxSkew,omitempty"`  //各个区域 Pod 数量的最大差值，默认 1
+}
+
+func (r *PlusAppSpread) GetTopologyKey() string {
+	if r.Topology == SpreadTopologyNode {
+		return corev1.LabelHostname
+	}
+	return corev1.LabelTopologyZone
+}
+
+func (r *PlusAppSpread) IsHard() bool {
+	return r.Mode == SpreadModeHard
+}
+
+func (r *PlusAppSpread) GetMaxSkew() int32 {
+	if r.MaxSkew > 0 {
+		return r.MaxSkew
+	}
+	return 1
+}
+
+// GetTopologySpreadConstraints 版本配置了 spread 时使用 spread，否则使用地域负载均衡的默认配置
+func (r *Plus) GetTopologySpreadConstraints(app *PlusApp) []corev1.TopologySpreadConstraint {
+	if app.Spread == nil {
+		return r.GetDefaultTopologySpreadConstraints(app)
+	}
+	whenUnsatisfiable := corev1.ScheduleAnyway
+	if app.Spread.IsHard() {
+		whenUnsatisfiable = corev1.DoNotSchedule
+	}
+	return []corev1.TopologySpreadConstraint{{
+		MaxSkew:           app.Spread.GetMaxSkew(),
+		TopologyKey:       app.Spread.GetTopologyKey(),
+		WhenUnsatisfiable: whenUnsatisfiable,
+		LabelSelector:     &metav1.LabelSelector{MatchLabels: r.GenerateAppLabels(app)},
+	}}
+}
+
+// GetAffinity 在 affinity 的基础上追加 spread 的 podAntiAffinity。
+// 只有 node 的 hard 模式为必须满足(每个节点最多一个 Pod)，zone 必须满足时副本数不能超过可用区数量，所以只作为优先条件
+func (r *Plus) GetAffinity(app *PlusApp) *corev1.Affinity {
+	if app.Spread == nil {
+		return app.Affinity
+	}
+
+	affinity := &corev1.Affinity{}
+	if app.Affinity != nil {
+		affinity = app.Affinity.DeepCopy()
+	}
+	if affinity.PodAntiAffinity == nil {
+		affinity.PodAntiAffinity = &corev1.PodAntiAffinity{}
+	}
+
+	term := corev1.PodAffinityTerm{
+		LabelSelector: &metav1.LabelSelector{MatchLabels: r.GenerateAppLabels(app)},
+		TopologyKey:   app.Spread.GetTopologyKey(),
+	}
+	if app.Spread.IsHard() && app.Spread.Topology == SpreadTopologyNode {
+		affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, term)
+	} else {
+		affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution = append(
+			affinity.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution,
+			corev1.WeightedPodAffinityTerm{Weight: 100, PodAffinityTerm: term})
+	}
+	return affinity
+}
+
+func (r *PlusAppSpread) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("spread")
+
+	if r.Topology != "" && !containsString(spreadTopologies, r.Topology) {
+		err := field.NotSupported(fldPath.Child("topology"), r.Topology, spreadTopologies)
+		return apierrors.NewInvalid(PlusKind, "topology", field.ErrorList{err})
+	}
+
+	if r.Mode != "" && !containsString(spreadModes, r.Mode) {
+		err := field.NotSupported(fldPath.Child("mode"), r.Mode, spreadModes)
+		return apierrors.NewInvalid(PlusKind, "mode", field.ErrorList{err})
+	}
+
+	if r.MaxSkew < 0 {
+		err := field.Invalid(fldPath.Child("maxSkew"), r.MaxSkew, "maxSkew must >= 0")
+		return apierrors.NewInvalid(PlusKind, "maxSkew", field.ErrorList{err})
+	}
+	return nil
+}
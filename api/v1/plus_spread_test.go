@@ -0,0 +1,64 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"testing"
+)
+
+func TestValidSpread(t *testing.T) {
+	tests := []struct {
+		r     PlusAppSpread
+		isErr bool
+	}{
+		{r: PlusAppSpread{}, isErr: false},
+		{r: PlusAppSpread{Topology: SpreadTopologyNode, Mode: SpreadModeHard, MaxSkew: 2}, isErr: false},
+		{r: PlusAppSpread{Topology: "region"}, isErr: true},
+		{r: PlusAppSpread{Mode: "strict"}, isErr: true},
+		{r: PlusAppSpread{MaxSkew: -1}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate(field.NewPath("spec"))
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestSpread(t *testing.T) {
+	affinity := &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{}}
+	app := &PlusApp{Version: "blue", Affinity: affinity}
+	plus := &Plus{ObjectMeta: metav1.ObjectMeta{Name: "svc-a"}, Spec: PlusSpec{
+		Apps:   []*PlusApp{app},
+		Policy: &PlusPolicy{Locality: &PlusPolicyLocality{}},
+	}}
+	require.Equal(t, affinity, plus.GetAffinity(app))
+	require.Equal(t, plus.GetDefaultTopologySpreadConstraints(app), plus.GetTopologySpreadConstraints(app))
+
+	// zone 即使是 hard 也只作为反亲和的优先条件
+	app.Spread = &PlusAppSpread{Mode: SpreadModeHard}
+	constraints := plus.GetTopologySpreadConstraints(app)
+	require.Len(t, constraints, 1)
+	require.Equal(t, corev1.LabelTopologyZone, constraints[0].TopologyKey)
+	require.Equal(t, corev1.DoNotSchedule, constraints[0].WhenUnsatisfiable)
+	require.Equal(t, int32(1), constraints[0].MaxSkew)
+	result := plus.GetAffinity(app)
+	require.Equal(t, affinity.NodeAffinity, result.NodeAffinity)
+	require.Nil(t, affinity.PodAntiAffinity)
+	require.Len(t, result.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution, 1)
+	require.Equal(t, plus.GenerateAppLabels(app), result.PodAntiAffinity.PreferredDuringSchedulingIgnoredDuringExecution[0].PodAffinityTerm.LabelSelector.MatchLabels)
+
+	app.Spread = &PlusAppSpread{Topology: SpreadTopologyNode, Mode: SpreadModeHard}
+	result = plus.GetAffinity(app)
+	require.Len(t, result.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution, 1)
+	require.Equal(t, corev1.LabelHostname, result.PodAntiAffinity.RequiredDuringSchedulingIgnoredDuringExecution[0].TopologyKey)
+
+	app.Spread = &PlusAppSpread{Topology: SpreadTopologyNode, MaxSkew: 2}
+	constraints = plus.GetTopologySpreadConstraints(app)
+	require.Equal(t, corev1.ScheduleAnyway, constraints[0].WhenUnsatisfiable)
+	require.Equal(t, int32(2), constraints[0].MaxSkew)
+}
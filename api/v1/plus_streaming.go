@@ -0,0 +1,156 @@
+package v1
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/golang/protobuf/ptypes/duration"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+)
+
+const (
+	StreamingModeWebsocket = "websocket"
+	StreamingModeStreaming = "streaming"
+)
+
+const (
+	DefaultStreamingIdleTimeout   = "1h"
+	DefaultStreamingDrainDuration = "30s"
+	// DefaultTerminationGracePeriodSeconds preStop 结束后留给应用退出的时间
+	DefaultTerminationGracePeriodSeconds = 30
+)
+
+// DefaultStreamingTcpKeepalive 长连接默认的 tcp keepalive，避免空闲连接被中间的负载均衡断开
+var DefaultStreamingTcpKeepalive = PlusPolicyTcpKeepalive{Probes: 3, Time: "60s", Interval: "10s"}
+
+// PlusPolicyStreaming 长连接模式，路由不设置超时也不重试，Pod 退出前等待连接迁移
+type PlusPolicyStreaming struct {
+	Mode          string                  `json:"mode,omitempty"`          //websocket(默认) 或 streaming(grpc 流、SSE 等)，websocket 到实例的连接不升级为 http2
+	IdleTimeout   string                  `json:"idleTimeout,omitempty"`   //连接空闲超时时间，默认 1h，connectionPool.http.idleTimeout 优先
+	TcpKeepalive  *PlusPolicyTcpKeepalive `json:"tcpKeepalive,omitempty"`  //默认 time 60s, interval 10s, probes 3，connectionPool.tcp.tcpKeepalive 优先
+	DrainDuration string                  `json:"drainDuration,omitempty"` //Pod 退出前(preStop)等待连接断开的时间，默认 30s，通过 sh -c sleep 实现，镜像中没有 sh 时设置为 0s
+}
+
+// GetStreaming 版本配置了长连接模式时优先使用版本的配置。
+// app 为 nil 时是网关按权重分流的默认路由，任意版本是长连接模式时默认路由也按照长连接处理
+func (r *Plus) GetStreaming(app *PlusApp) *PlusPolicyStreaming {
+	if app != nil && app.Policy != nil && app.Policy.Streaming != nil {
+		return app.Policy.Streaming
+	}
+	if r.Spec.Policy != nil && r.Spec.Policy.Streaming != nil {
+		return r.Spec.Policy.Streaming
+	}
+	if app == nil {
+		for _, e := range r.Spec.Apps {
+			if e.Policy != nil && e.Policy.Streaming != nil {
+				return e.Policy.Streaming
+			}
+		}
+	}
+	return nil
+}
+
+func (r *PlusPolicyStreaming) GetMode() string {
+	if r.Mode == "" {
+		return StreamingModeWebsocket
+	}
+	return r.Mode
+}
+
+func (r *PlusPolicyStreaming) GetDrainDuration() time.Duration {
+	drain := r.DrainDuration
+	if drain == "" {
+		drain = DefaultStreamingDrainDuration
+	}
+	d, err := time.ParseDuration(drain)
+	if err != nil {
+		return 0
+	}
+	return d
+}
+
+func (r *PlusPolicyStreaming) getIdleTimeout() *duration.Duration {
+	if r.IdleTimeout == "" {
+		return parseDuration(DefaultStreamingIdleTimeout)
+	}
+	return parseDuration(r.IdleTimeout)
+}
+
+func (r *PlusPolicyStreaming) getTcpKeepalive() *istioapiv1.ConnectionPoolSettings_TCPSettings_TcpKeepalive {
+	keepalive := r.TcpKeepalive
+	if keepalive == nil {
+		keepalive = &DefaultStreamingTcpKeepalive
+	}
+	return &istioapiv1.ConnectionPoolSettings_TCPSettings_TcpKeepalive{
+		Probes:   keepalive.Probes,
+		Time:     parseDuration(keepalive.Time),
+		Interval: parseDuration(keepalive.Interval),
+	}
+}
+
+// ApplyConnectionPool 在连接池配置上补充长连接需要的 keepalive 和空闲超时，connectionPool 中已经配置的优先
+func (r *PlusPolicyStreaming) ApplyConnectionPool(settings *istioapiv1.ConnectionPoolSettings) *istioapiv1.ConnectionPoolSettings {
+	if r == nil {
+		return settings
+	}
+	if settings.Tcp == nil {
+		settings.Tcp = &istioapiv1.ConnectionPoolSettings_TCPSettings{}
+	}
+	if settings.Tcp.TcpKeepalive == nil {
+		settings.Tcp.TcpKeepalive = r.getTcpKeepalive()
+	}
+	if settings.Http == nil {
+		settings.Http = &istioapiv1.ConnectionPoolSettings_HTTPSettings{}
+	}
+	if settings.Http.IdleTimeout == nil {
+		settings.Http.IdleTimeout = r.getIdleTimeout()
+	}
+	// websocket 升级为 http2 后依赖 extended CONNECT，实例不一定支持
+	if r.GetMode() == StreamingModeWebsocket && settings.Http.H2UpgradePolicy == istioapiv1.ConnectionPoolSettings_HTTPSettings_DEFAULT {
+		settings.Http.H2UpgradePolicy = istioapiv1.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE
+	}
+	return settings
+}
+
+func (r *PlusPolicyStreaming) Validate(fldPath *field.Path) error {
+	fldPath = fldPath.Child("streaming")
+
+	if r.Mode != "" && r.Mode != StreamingModeWebsocket && r.Mode != StreamingModeStreaming {
+		err := field.NotSupported(fldPath.Child("mode"), r.Mode, []string{StreamingModeWebsocket, StreamingModeStreaming})
+		return apierrors.NewInvalid(PlusKind, "mode", field.ErrorList{err})
+	}
+
+	if err := validateOptionalDuration(fldPath.Child("idleTimeout"), "idleTimeout", r.IdleTimeout); err != nil {
+		return err
+	}
+
+	if e := r.TcpKeepalive; e != nil {
+		if err := validateOptionalDuration(fldPath.Child("tcpKeepalive", "time"), "time", e.Time); err != nil {
+			return err
+		}
+		if err := validateOptionalDuration(fldPath.Child("tcpKeepalive", "interval"), "interval", e.Interval); err != nil {
+			return err
+		}
+	}
+
+	return validateOptionalDuration(fldPath.Child("drainDuration"), "drainDuration", r.DrainDuration)
+}
+
+// validateStreaming 配置了 terminationGracePeriodSeconds 时必须大于 preStop 等待的时间，否则连接会被强制断开
+func (r *Plus) validateStreaming(fldPath *field.Path) error {
+	for i, app := range r.Spec.Apps {
+		streaming := r.GetStreaming(app)
+		if streaming == nil || app.TerminationGracePeriodSeconds == 0 {
+			continue
+		}
+		drain := streaming.GetDrainDuration()
+		if time.Duration(app.TerminationGracePeriodSeconds)*time.Second <= drain {
+			err := field.Invalid(fldPath.Child("apps").Index(i).Child("terminationGracePeriodSeconds"), app.TerminationGracePeriodSeconds,
+				fmt.Sprintf("terminationGracePeriodSeconds must > streaming drainDuration(%s)", drain))
+			return apierrors.NewInvalid(PlusKind, "terminationGracePeriodSeconds", field.ErrorList{err})
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package v1
+
+import (
+	"github.com/stretchr/testify/require"
+	istioapiv1 "istio.io/api/networking/v1alpha3"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func TestValidStreaming(t *testing.T) {
+	plus := func(streaming *PlusPolicyStreaming, terminationGracePeriodSeconds int64) Plus {
+		return Plus{ObjectMeta: metav1.ObjectMeta{Name: "chat"}, Spec: PlusSpec{
+			Policy: &PlusPolicy{Timeout: "10s", Streaming: streaming},
+			Apps: []*PlusApp{{Version: "blue", MinReplicas: 1, MaxReplicas: 1, Port: 8080, Protocol: ProtocolHTTP,
+				TerminationGracePeriodSeconds: terminationGracePeriodSeconds}},
+		}}
+	}
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: plus(&PlusPolicyStreaming{}, 0), isErr: false},
+		{r: plus(&PlusPolicyStreaming{Mode: StreamingModeStreaming, IdleTimeout: "2h", DrainDuration: "60s"}, 90), isErr: false},
+		{r: plus(&PlusPolicyStreaming{Mode: "sse"}, 0), isErr: true},
+		{r: plus(&PlusPolicyStreaming{IdleTimeout: "2"}, 0), isErr: true},
+		{r: plus(&PlusPolicyStreaming{TcpKeepalive: &PlusPolicyTcpKeepalive{Time: "1"}}, 0), isErr: true},
+		{r: plus(&PlusPolicyStreaming{DrainDuration: "60s"}, 60), isErr: true},
+		{r: plus(&PlusPolicyStreaming{}, 30), isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate()
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestGetStreaming(t *testing.T) {
+	streaming := &PlusPolicyStreaming{Mode: StreamingModeStreaming}
+	blue := &PlusApp{Version: "blue"}
+	green := &PlusApp{Version: "green", Policy: &PlusAppPolicy{Streaming: streaming}}
+	plus := &Plus{Spec: PlusSpec{Apps: []*PlusApp{blue, green}}}
+
+	require.Nil(t, plus.GetStreaming(blue))
+	require.Equal(t, streaming, plus.GetStreaming(green))
+	// 默认路由会转发到长连接的版本
+	require.Equal(t, streaming, plus.GetStreaming(nil))
+
+	plus.Spec.Policy = &PlusPolicy{Streaming: &PlusPolicyStreaming{}}
+	require.Equal(t, plus.Spec.Policy.Streaming, plus.GetStreaming(blue))
+	require.Equal(t, streaming, plus.GetStreaming(green))
+	require.Equal(t, StreamingModeWebsocket, plus.GetStreaming(blue).GetMode())
+	require.Equal(t, 30*time.Second, plus.GetStreaming(blue).GetDrainDuration())
+}
+
+func TestStreamingConnectionPool(t *testing.T) {
+	var empty *PlusPolicyStreaming
+	settings := &istioapiv1.ConnectionPoolSettings{}
+	require.Equal(t, settings, empty.ApplyConnectionPool(settings))
+
+	settings = (&PlusPolicyStreaming{}).ApplyConnectionPool(&istioapiv1.ConnectionPoolSettings{})
+	require.Equal(t, int64(3600), settings.Http.IdleTimeout.Seconds)
+	require.Equal(t, uint32(3), settings.Tcp.TcpKeepalive.Probes)
+	require.Equal(t, int64(60), settings.Tcp.TcpKeepalive.Time.Seconds)
+	require.Equal(t, istioapiv1.ConnectionPoolSettings_HTTPSettings_DO_NOT_UPGRADE, settings.Http.H2UpgradePolicy)
+
+	// connectionPool 中已经配置的优先
+	pool := &PlusPolicyConnectionPool{
+		Tcp:  &PlusPolicyConnectionPoolTcp{TcpKeepalive: &PlusPolicyTcpKeepalive{Probes: 5}},
+		Http: &PlusPolicyConnectionPoolHttp{IdleTimeout: "10m", H2UpgradePolicy: "UPGRADE"},
+	}
+	settings = (&PlusPolicyStreaming{}).ApplyConnectionPool(pool.GetConnectionPoolSettings(100))
+	require.Equal(t, int64(600), settings.Http.IdleTimeout.Seconds)
+	require.Equal(t, uint32(5), settings.Tcp.TcpKeepalive.Probes)
+	require.Equal(t, istioapiv1.ConnectionPoolSettings_HTTPSettings_UPGRADE, settings.Http.H2UpgradePolicy)
+
+	settings = (&PlusPolicyStreaming{Mode: StreamingModeStreaming}).ApplyConnectionPool(&istioapiv1.ConnectionPoolSettings{})
+	require.Equal(t, istioapiv1.ConnectionPoolSettings_HTTPSettings_DEFAULT, settings.Http.H2UpgradePolicy)
+}
@@ -19,6 +19,7 @@ package v1
 import (
 	"fmt"
 	"strings"
+	"time"
 
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -40,6 +41,14 @@ type PlusSpec struct {
 	Policy *PlusPolicy `json:"policy,omitempty"`
 	// Apps 描述具体部署的程序，可以有多个版本
 	Apps []*PlusApp `json:"apps,omitempty"`
+	// Access 描述网格内哪些服务可以访问
+	Access *PlusAccess `json:"access,omitempty"`
+	// Service 描述 Service 的类型，以及是否为每个版本创建 Service
+	Service *PlusService `json:"service,omitempty"`
+	// Egress 网格外的依赖，例如数据库和第三方接口
+	Egress []*PlusEgress `json:"egress,omitempty"`
+	// DependsOn 依赖的 Plus 或者服务，sidecar 只下发这些服务的配置
+	DependsOn []*PlusDependency `json:"dependsOn,omitempty"`
 }
 
 // PlusStatus defines the observed state of Plus
@@ -49,6 +58,21 @@ type PlusStatus struct {
 	AvailableReplicas map[string]int32 `json:"availableReplicas,omitempty"`
 	Success           bool             `json:"success,omitempty"`
 	Desc              PlusDesc         `json:"desc,omitempty"`
+	// Fault 故障注入的实验窗口
+	Fault *PlusFaultStatus `json:"fault,omitempty"`
+	// URLs 网关所有 listener 生效的访问地址
+	URLs []string `json:"urls,omitempty"`
+	// Aliases 网格内固定访问某个版本的域名，key 为版本
+	Aliases map[string]string `json:"aliases,omitempty"`
+	// Conditions 例如和其他 Plus 的域名冲突
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+type PlusFaultStatus struct {
+	Hash      string       `json:"hash,omitempty"` //故障配置的摘要，变化时重新计算 startedAt
+	StartedAt metav1.Time  `json:"startedAt,omitempty"`
+	ExpiresAt *metav1.Time `json:"expiresAt,omitempty"`
+	Active    bool         `json:"active,omitempty"`
 }
 
 type PlusDesc struct {
@@ -120,6 +144,9 @@ func (r *Plus) GenerateAppTemplateLabels(app *PlusApp) map[string]string {
 	for k, v := range app.TemplateLabels {
 		labels[k] = v
 	}
+	if r.IsWaitReady() {
+		labels[DependenciesReadinessGateLabel] = "true"
+	}
 	return labels
 }
 
@@ -150,27 +177,63 @@ func (r *Plus) GenerateStatusDesc() {
 	}
 
 	r.Status.Desc.PrefixPath = r.GeneratePrefixPath()
+	r.Status.URLs = r.GenerateURLs()
+	r.Status.Aliases = r.GetVersionAliases()
 }
 
-func (r *Plus) GeneratePrefixPath() string {
-	prefixPath := ""
-	if r.Spec.Gateway == nil {
-		return ""
+// UpdateFaultStatus 记录故障注入的实验窗口，故障配置变化时重新开始，返回距离故障过期的时间，0 表示不需要定时调和。
+// 故障从 spec 中移除后保留最近一次的实验窗口，清空 hash 使再次添加相同的故障时重新开始
+func (r *Plus) UpdateFaultStatus(now time.Time) time.Duration {
+	if r.Spec.Policy == nil || r.Spec.Policy.Fault == nil {
+		if r.Status.Fault != nil {
+			r.Status.Fault.Hash = ""
+			if r.Status.Fault.Active && (r.Status.Fault.ExpiresAt == nil || r.Status.Fault.ExpiresAt.After(now)) {
+				expiresAt := metav1.NewTime(now.Truncate(time.Second))
+				r.Status.Fault.ExpiresAt = &expiresAt
+			}
+			r.Status.Fault.Active = false
+		}
+		return 0
 	}
-	if r.Spec.Gateway.PathPrefix == nil {
-		prefixPath = fmt.Sprintf("/%s", r.GetName())
-		return prefixPath
+
+	hash := r.Spec.Policy.Fault.Hash()
+	if r.Status.Fault == nil || r.Status.Fault.Hash != hash {
+		r.Status.Fault = &PlusFaultStatus{Hash: hash, StartedAt: metav1.NewTime(now.Truncate(time.Second))}
 	}
 
-	if *r.Spec.Gateway.PathPrefix == "" {
-		return ""
+	r.Status.Fault.ExpiresAt = r.Spec.Policy.Fault.GetExpiresAt(r.Status.Fault.StartedAt)
+	if r.Status.Fault.ExpiresAt == nil {
+		r.Status.Fault.Active = true
+		return 0
 	}
 
-	if strings.HasPrefix(*r.Spec.Gateway.PathPrefix, "/") {
-		return *r.Spec.Gateway.PathPrefix
-	} else {
-		return fmt.Sprintf("/%s", *r.Spec.Gateway.PathPrefix)
+	remaining := r.Status.Fault.ExpiresAt.Sub(now)
+	r.Status.Fault.Active = remaining > 0
+	if remaining < 0 {
+		return 0
+	}
+	return remaining
+}
+
+// IsFaultExpired spec 中的故障注入已经过期，需要由控制器移除
+func (r *Plus) IsFaultExpired() bool {
+	return r.Spec.Policy != nil && r.Spec.Policy.Fault != nil &&
+		r.Status.Fault != nil && !r.Status.Fault.Active && r.Status.Fault.ExpiresAt != nil
+}
+
+// IsFaultActive 故障注入是否在实验窗口内
+func (r *Plus) IsFaultActive() bool {
+	return r.Status.Fault != nil && r.Status.Fault.Active
+}
+
+func (r *Plus) GeneratePrefixPath() string {
+	if r.Spec.Gateway == nil {
+		return ""
+	}
+	if r.Spec.Gateway.PathPrefix == nil {
+		return fmt.Sprintf("/%s", r.GetName())
 	}
+	return formatPrefixPath(*r.Spec.Gateway.PathPrefix)
 }
 
 func (r *Plus) Validate() error {
@@ -192,14 +255,84 @@ func (r *Plus) Validate() error {
 		}
 	}
 
+	if e := r.Spec.Access; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
+	if e := r.Spec.Service; e != nil {
+		if err := e.Validate(fldPath); err != nil {
+			return err
+		}
+	}
+
 	for _, e := range r.Spec.Apps {
 		if err := e.Validate(fldPath); err != nil {
 			return err
 		}
+		if err := r.validateAppRetryBudget(fldPath, e); err != nil {
+			return err
+		}
+	}
+
+	if err := r.validateMaintenance(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateGatewayPrincipals(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateServiceAccounts(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateProtocols(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateGrpc(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateStreaming(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateEgress(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateDependsOn(fldPath); err != nil {
+		return err
+	}
+
+	if err := r.validateLocality(fldPath); err != nil {
+		return err
 	}
 	return nil
 }
 
+// validateAppRetryBudget 版本可以只覆盖重试或超时中的一个，按照生效的组合校验重试总耗时
+func (r *Plus) validateAppRetryBudget(fldPath *field.Path, app *PlusApp) error {
+	var retries *PlusPolicyRetries
+	var timeout string
+	if r.Spec.Policy != nil {
+		retries = r.Spec.Policy.Retries
+		timeout = r.Spec.Policy.Timeout
+	}
+	if app.Policy != nil {
+		if app.Policy.Retries != nil {
+			retries = app.Policy.Retries
+		}
+		if app.Policy.Timeout != "" {
+			timeout = app.Policy.Timeout
+		}
+	}
+	return validateRetryBudget(fldPath.Child("app", "policy"), retries, timeout)
+}
+
 //+kubebuilder:object:root=true
 
 // PlusList contains a list of Plus
@@ -3,7 +3,9 @@ package v1
 import (
 	"github.com/stretchr/testify/require"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	"testing"
+	"time"
 )
 
 func TestValidPlus(t *testing.T) {
@@ -26,3 +28,106 @@ func TestValidPlus(t *testing.T) {
 		}
 	}
 }
+
+func TestValidAppRetryBudget(t *testing.T) {
+	app := func(policy *PlusAppPolicy) *PlusApp {
+		return &PlusApp{Version: "blue", MinReplicas: 1, MaxReplicas: 1, Port: 8080, Protocol: "http", Policy: policy}
+	}
+	policy := &PlusPolicy{Timeout: "10s", Retries: &PlusPolicyRetries{Attempts: 3, PerTryTimeout: "2s"}}
+
+	tests := []struct {
+		r     Plus
+		isErr bool
+	}{
+		{r: Plus{ObjectMeta: metav1.ObjectMeta{Name: "aaa"}, Spec: PlusSpec{Policy: policy, Apps: []*PlusApp{app(nil)}}}, isErr: false},
+		{r: Plus{ObjectMeta: metav1.ObjectMeta{Name: "aaa"}, Spec: PlusSpec{Policy: policy, Apps: []*PlusApp{app(&PlusAppPolicy{Timeout: "30s"})}}}, isErr: false},
+		{r: Plus{ObjectMeta: metav1.ObjectMeta{Name: "aaa"}, Spec: PlusSpec{Policy: policy, Apps: []*PlusApp{app(&PlusAppPolicy{Timeout: "5s"})}}}, isErr: true},
+		{r: Plus{ObjectMeta: metav1.ObjectMeta{Name: "aaa"}, Spec: PlusSpec{Policy: policy, Apps: []*PlusApp{app(&PlusAppPolicy{Retries: &PlusPolicyRetries{Attempts: 10, PerTryTimeout: "2s"}})}}}, isErr: true},
+	}
+	for _, test := range tests {
+		err := test.r.Validate()
+		if test.isErr {
+			require.NotNil(t, err)
+		} else {
+			require.Nil(t, err)
+		}
+	}
+}
+
+func TestUpdateFaultStatus(t *testing.T) {
+	now := time.Date(2022, 12, 1, 10, 0, 0, 0, time.UTC)
+	r := Plus{Spec: PlusSpec{Policy: &PlusPolicy{Fault: &PlusPolicyFault{Duration: "10m"}}}}
+
+	require.Equal(t, 10*time.Minute, r.UpdateFaultStatus(now))
+	require.True(t, r.IsFaultActive())
+	require.Equal(t, now, r.Status.Fault.StartedAt.Time)
+	require.Equal(t, now.Add(10*time.Minute), r.Status.Fault.ExpiresAt.Time)
+
+	require.Equal(t, 4*time.Minute, r.UpdateFaultStatus(now.Add(6*time.Minute)))
+	require.True(t, r.IsFaultActive())
+
+	require.Equal(t, time.Duration(0), r.UpdateFaultStatus(now.Add(11*time.Minute)))
+	require.False(t, r.IsFaultActive())
+	require.True(t, r.IsFaultExpired())
+	require.Equal(t, now, r.Status.Fault.StartedAt.Time)
+
+	// 控制器移除过期的故障后保留实验窗口，再次添加相同的故障时重新开始
+	r.Spec.Policy.Fault = nil
+	r.UpdateFaultStatus(now.Add(12 * time.Minute))
+	require.False(t, r.IsFaultExpired())
+	require.Equal(t, now, r.Status.Fault.StartedAt.Time)
+	require.Equal(t, now.Add(10*time.Minute), r.Status.Fault.ExpiresAt.Time)
+	r.Spec.Policy.Fault = &PlusPolicyFault{Duration: "10m"}
+	require.Equal(t, 10*time.Minute, r.UpdateFaultStatus(now.Add(12*time.Minute)))
+	require.True(t, r.IsFaultActive())
+
+	r.Spec.Policy.Fault = &PlusPolicyFault{Duration: "10m", Scope: FaultScopeMesh}
+	require.Equal(t, 10*time.Minute, r.UpdateFaultStatus(now.Add(11*time.Minute)))
+	require.True(t, r.IsFaultActive())
+	require.Equal(t, now.Add(11*time.Minute), r.Status.Fault.StartedAt.Time)
+
+	r.Spec.Policy.Fault = &PlusPolicyFault{}
+	require.Equal(t, time.Duration(0), r.UpdateFaultStatus(now))
+	require.True(t, r.IsFaultActive())
+
+	// 手动移除没有到期的故障时，实验窗口在移除时结束
+	r.Spec.Policy.Fault = nil
+	r.UpdateFaultStatus(now.Add(13 * time.Minute))
+	require.False(t, r.IsFaultActive())
+	require.Equal(t, now.Add(13*time.Minute), r.Status.Fault.ExpiresAt.Time)
+}
+
+func TestServiceAccounts(t *testing.T) {
+	plus := &Plus{
+		ObjectMeta: metav1.ObjectMeta{Name: "svc-a", Namespace: "default"},
+		Spec: PlusSpec{Apps: []*PlusApp{
+			{Version: "blue", ServiceAccount: &PlusServiceAccount{Annotations: map[string]string{"iam.gke.io/gcp-service-account": "a@p.iam.gserviceaccount.com"}}},
+			{Version: "green", ServiceAccount: &PlusServiceAccount{}},
+			{Version: "canary", ServiceAccount: &PlusServiceAccount{PerVersion: true}},
+			{Version: "legacy", ServiceAccount: &PlusServiceAccount{Name: "legacy"}},
+			{Version: "old"},
+		}},
+	}
+	require.Equal(t, "svc-a", plus.GetServiceAccountName(plus.Spec.Apps[0]))
+	require.Equal(t, "svc-a-canary", plus.GetServiceAccountName(plus.Spec.Apps[2]))
+	require.Equal(t, "legacy", plus.GetServiceAccountName(plus.Spec.Apps[3]))
+	require.Equal(t, "", plus.GetServiceAccountName(plus.Spec.Apps[4]))
+
+	require.Equal(t, map[string]map[string]string{
+		"svc-a":        {"iam.gke.io/gcp-service-account": "a@p.iam.gserviceaccount.com"},
+		"svc-a-canary": {},
+	}, plus.GenerateServiceAccounts())
+
+	require.Equal(t, []string{
+		"cluster.local/ns/default/sa/svc-a",
+		"cluster.local/ns/default/sa/svc-a-canary",
+		"cluster.local/ns/default/sa/legacy",
+	}, plus.GeneratePrincipals())
+	require.Nil(t, plus.validateServiceAccounts(field.NewPath("spec")))
+
+	plus.Spec.Apps[1].ServiceAccount.Annotations = map[string]string{"iam.gke.io/gcp-service-account": "b@p.iam.gserviceaccount.com"}
+	require.NotNil(t, plus.validateServiceAccounts(field.NewPath("spec")))
+
+	require.NotNil(t, (&PlusServiceAccount{Name: "legacy", PerVersion: true}).Validate(field.NewPath("spec")))
+	require.NotNil(t, (&PlusServiceAccount{Name: "Legacy"}).Validate(field.NewPath("spec")))
+}
@@ -17,8 +17,14 @@ limitations under the License.
 package v1
 
 import (
+	"context"
+	"strings"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 	"sigs.k8s.io/controller-runtime/pkg/webhook"
 )
@@ -26,7 +32,11 @@ import (
 // log is for logging in this package.
 var pluslog = logf.Log.WithName("plus-resource")
 
+// webhookClient 用于校验和其他 Plus 的冲突，需要 GatewayHostsField 索引
+var webhookClient client.Reader
+
 func (r *Plus) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	webhookClient = mgr.GetClient()
 	return ctrl.NewWebhookManagedBy(mgr).
 		For(r).
 		Complete()
@@ -45,6 +55,33 @@ func (r *Plus) Default() {
 		if app.MaxReplicas == 0 {
 			app.MaxReplicas = app.MinReplicas
 		}
+		if app.Policy != nil && app.Policy.OutlierDetection != nil {
+			if app.Policy.OutlierDetection.MaxEjectionPercent <= 0 {
+				app.Policy.OutlierDetection.MaxEjectionPercent = 10
+			}
+		}
+	}
+
+	if r.Spec.Gateway != nil && r.Spec.Gateway.Maintenance != nil {
+		if r.Spec.Gateway.Maintenance.Status == 0 {
+			r.Spec.Gateway.Maintenance.Status = 503
+		}
+	}
+
+	if r.Spec.Gateway != nil {
+		if r.Spec.Gateway.Cors != nil {
+			r.Spec.Gateway.Cors.Default()
+		}
+		for _, route := range r.Spec.Gateway.Route {
+			if route != nil && route.Cors != nil {
+				route.Cors.Default()
+			}
+		}
+		for _, listener := range r.Spec.Gateway.Listeners {
+			if listener.Cors != nil {
+				listener.Cors.Default()
+			}
+		}
 	}
 
 	if r.Spec.Policy != nil {
@@ -67,16 +104,27 @@ func (r *Plus) ValidateCreate() error {
 	if err := r.Validate(); err != nil {
 		return err
 	}
-	return nil
+	if err := r.validateDependencyCycles(); err != nil {
+		return err
+	}
+	return r.validateConflicts(nil)
 }
 
 // ValidateUpdate implements webhook.Validator so a webhook will be registered for the type
 func (r *Plus) ValidateUpdate(old runtime.Object) error {
 	pluslog.Info("validate update", "name", r.Name)
+	// 删除中的 Plus 只会移除 finalizer，不能因为校验失败阻止删除
+	if r.GetDeletionTimestamp() != nil {
+		return nil
+	}
 	if err := r.Validate(); err != nil {
 		return err
 	}
-	return nil
+	if err := r.validateDependencyCycles(); err != nil {
+		return err
+	}
+	oldPlus, _ := old.(*Plus)
+	return r.validateConflicts(oldPlus)
 }
 
 // ValidateDelete implements webhook.Validator so a webhook will be registered for the type
@@ -87,3 +135,54 @@ func (r *Plus) ValidateDelete() error {
 	}
 	return nil
 }
+
+// validateConflicts 拒绝和其他 Plus 的域名和路径前缀重叠的配置。
+// 更新时只拒绝本次修改引入的冲突，已经存在的冲突由 Conflict condition 提示，不能阻止其他字段的修改
+func (r *Plus) validateConflicts(old *Plus) error {
+	if webhookClient == nil {
+		return nil
+	}
+	conflicts, err := r.ListConflicts(context.TODO(), webhookClient)
+	if err != nil {
+		return err
+	}
+	if old != nil && len(conflicts) > 0 {
+		existing, err := old.ListConflicts(context.TODO(), webhookClient)
+		if err != nil {
+			return err
+		}
+		conflicts = subtractStrings(conflicts, existing)
+	}
+	if len(conflicts) > 0 {
+		err := field.Invalid(field.NewPath("spec", "gateway"), r.GetGatewayHosts(), strings.Join(conflicts, "; "))
+		return apierrors.NewInvalid(PlusKind, "gateway", field.ErrorList{err})
+	}
+	return nil
+}
+
+// validateDependencyCycles 拒绝和依赖的 Plus 互相 waitReady 的配置
+func (r *Plus) validateDependencyCycles() error {
+	if webhookClient == nil {
+		return nil
+	}
+	cycles, err := r.ListDependencyCycles(context.TODO(), webhookClient)
+	if err != nil {
+		return err
+	}
+	if len(cycles) > 0 {
+		err := field.Invalid(field.NewPath("spec", "dependsOn"), strings.Join(cycles, ", "), "waitReady dependencies can't wait for each other")
+		return apierrors.NewInvalid(PlusKind, "dependsOn", field.ErrorList{err})
+	}
+	return nil
+}
+
+// subtractStrings values 中不在 excludes 中的值
+func subtractStrings(values, excludes []string) []string {
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if !containsString(excludes, v) {
+			result = append(result, v)
+		}
+	}
+	return result
+}
@@ -99,6 +99,9 @@ var _ = BeforeSuite(func() {
 	})
 	Expect(err).NotTo(HaveOccurred())
 
+	err = SetupGatewayHostsIndex(mgr)
+	Expect(err).NotTo(HaveOccurred())
+
 	err = (&Plus{}).SetupWebhookWithManager(mgr)
 	Expect(err).NotTo(HaveOccurred())
 
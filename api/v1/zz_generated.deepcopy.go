@@ -23,7 +23,9 @@ package v1
 
 import (
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -53,6 +55,72 @@ func (in *Plus) DeepCopyObject() runtime.Object {
 	return nil
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusAccess) DeepCopyInto(out *PlusAccess) {
+	*out = *in
+	if in.AllowFrom != nil {
+		in, out := &in.AllowFrom, &out.AllowFrom
+		*out = make([]*PlusAccessSource, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusAccessSource)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusAccess.
+func (in *PlusAccess) DeepCopy() *PlusAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusAccessSource) DeepCopyInto(out *PlusAccessSource) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.ServiceAccounts != nil {
+		in, out := &in.ServiceAccounts, &out.ServiceAccounts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Pluses != nil {
+		in, out := &in.Pluses, &out.Pluses
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusAccessSource.
+func (in *PlusAccessSource) DeepCopy() *PlusAccessSource {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusAccessSource)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusApp) DeepCopyInto(out *PlusApp) {
 	*out = *in
@@ -116,6 +184,31 @@ func (in *PlusApp) DeepCopyInto(out *PlusApp) {
 		}
 	}
 	out.Scale = in.Scale
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(PlusHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Policy != nil {
+		in, out := &in.Policy, &out.Policy
+		*out = new(PlusAppPolicy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ServiceAccount != nil {
+		in, out := &in.ServiceAccount, &out.ServiceAccount
+		*out = new(PlusServiceAccount)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Spread != nil {
+		in, out := &in.Spread, &out.Spread
+		*out = new(PlusAppSpread)
+		**out = **in
+	}
+	if in.Rollout != nil {
+		in, out := &in.Rollout, &out.Rollout
+		*out = new(PlusAppRollout)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusApp.
@@ -128,6 +221,46 @@ func (in *PlusApp) DeepCopy() *PlusApp {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusAppPolicy) DeepCopyInto(out *PlusAppPolicy) {
+	*out = *in
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(PlusPolicyRetries)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(PlusPolicyOutlierDetection)
+		**out = **in
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(PlusPolicyLoadBalancer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionPool != nil {
+		in, out := &in.ConnectionPool, &out.ConnectionPool
+		*out = new(PlusPolicyConnectionPool)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Streaming != nil {
+		in, out := &in.Streaming, &out.Streaming
+		*out = new(PlusPolicyStreaming)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusAppPolicy.
+func (in *PlusAppPolicy) DeepCopy() *PlusAppPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusAppPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusAppProbe) DeepCopyInto(out *PlusAppProbe) {
 	*out = *in
@@ -148,6 +281,81 @@ func (in *PlusAppProbe) DeepCopy() *PlusAppProbe {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusAppRollout) DeepCopyInto(out *PlusAppRollout) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusAppRollout.
+func (in *PlusAppRollout) DeepCopy() *PlusAppRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusAppRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusAppSpread) DeepCopyInto(out *PlusAppSpread) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusAppSpread.
+func (in *PlusAppSpread) DeepCopy() *PlusAppSpread {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusAppSpread)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusDelegateRoute) DeepCopyInto(out *PlusDelegateRoute) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusDelegateRoute.
+func (in *PlusDelegateRoute) DeepCopy() *PlusDelegateRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusDelegateRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusDependency) DeepCopyInto(out *PlusDependency) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusDependency.
+func (in *PlusDependency) DeepCopy() *PlusDependency {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusDependency)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusDesc) DeepCopyInto(out *PlusDesc) {
 	*out = *in
@@ -163,6 +371,86 @@ func (in *PlusDesc) DeepCopy() *PlusDesc {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusEgress) DeepCopyInto(out *PlusEgress) {
+	*out = *in
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]PlusEgressPort, len(*in))
+		copy(*out, *in)
+	}
+	if in.Endpoints != nil {
+		in, out := &in.Endpoints, &out.Endpoints
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Tls != nil {
+		in, out := &in.Tls, &out.Tls
+		*out = new(PlusEgressTls)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusEgress.
+func (in *PlusEgress) DeepCopy() *PlusEgress {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusEgress)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusEgressPort) DeepCopyInto(out *PlusEgressPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusEgressPort.
+func (in *PlusEgressPort) DeepCopy() *PlusEgressPort {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusEgressPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusEgressTls) DeepCopyInto(out *PlusEgressTls) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusEgressTls.
+func (in *PlusEgressTls) DeepCopy() *PlusEgressTls {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusEgressTls)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusFaultStatus) DeepCopyInto(out *PlusFaultStatus) {
+	*out = *in
+	in.StartedAt.DeepCopyInto(&out.StartedAt)
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusFaultStatus.
+func (in *PlusFaultStatus) DeepCopy() *PlusFaultStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusFaultStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusGateway) DeepCopyInto(out *PlusGateway) {
 	*out = *in
@@ -203,6 +491,48 @@ func (in *PlusGateway) DeepCopyInto(out *PlusGateway) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(PlusHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Maintenance != nil {
+		in, out := &in.Maintenance, &out.Maintenance
+		*out = new(PlusGatewayMaintenance)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Redirects != nil {
+		in, out := &in.Redirects, &out.Redirects
+		*out = make([]*PlusGatewayRedirect, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusGatewayRedirect)
+				**out = **in
+			}
+		}
+	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(PlusGatewayAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Listeners != nil {
+		in, out := &in.Listeners, &out.Listeners
+		*out = make([]*PlusGatewayListener, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusGatewayListener)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Grpc != nil {
+		in, out := &in.Grpc, &out.Grpc
+		*out = new(PlusGatewayGrpc)
+		(*in).DeepCopyInto(*out)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGateway.
@@ -215,6 +545,41 @@ func (in *PlusGateway) DeepCopy() *PlusGateway {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayAccess) DeepCopyInto(out *PlusGatewayAccess) {
+	*out = *in
+	if in.AllowIPs != nil {
+		in, out := &in.AllowIPs, &out.AllowIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.DenyIPs != nil {
+		in, out := &in.DenyIPs, &out.DenyIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Jwt != nil {
+		in, out := &in.Jwt, &out.Jwt
+		*out = new(PlusGatewayJwt)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.GatewayPrincipals != nil {
+		in, out := &in.GatewayPrincipals, &out.GatewayPrincipals
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayAccess.
+func (in *PlusGatewayAccess) DeepCopy() *PlusGatewayAccess {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayAccess)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusGatewayCors) DeepCopyInto(out *PlusGatewayCors) {
 	*out = *in
@@ -223,6 +588,16 @@ func (in *PlusGatewayCors) DeepCopyInto(out *PlusGatewayCors) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AllowOriginPrefixes != nil {
+		in, out := &in.AllowOriginPrefixes, &out.AllowOriginPrefixes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.AllowOriginRegexes != nil {
+		in, out := &in.AllowOriginRegexes, &out.AllowOriginRegexes
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.AllowMethods != nil {
 		in, out := &in.AllowMethods, &out.AllowMethods
 		*out = make([]string, len(*in))
@@ -238,6 +613,11 @@ func (in *PlusGatewayCors) DeepCopyInto(out *PlusGatewayCors) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.AllowCredentials != nil {
+		in, out := &in.AllowCredentials, &out.AllowCredentials
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayCors.
@@ -251,35 +631,329 @@ func (in *PlusGatewayCors) DeepCopy() *PlusGatewayCors {
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusGatewayRoute) DeepCopyInto(out *PlusGatewayRoute) {
+func (in *PlusGatewayEndpoint) DeepCopyInto(out *PlusGatewayEndpoint) {
 	*out = *in
-	if in.HeadersMatch != nil {
-		in, out := &in.HeadersMatch, &out.HeadersMatch
-		*out = make([]map[string]string, len(*in))
-		for i := range *in {
-			if (*in)[i] != nil {
-				in, out := &(*in)[i], &(*out)[i]
-				*out = make(map[string]string, len(*in))
-				for key, val := range *in {
-					(*out)[key] = val
-				}
-			}
-		}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayRoute.
-func (in *PlusGatewayRoute) DeepCopy() *PlusGatewayRoute {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayEndpoint.
+func (in *PlusGatewayEndpoint) DeepCopy() *PlusGatewayEndpoint {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusGatewayRoute)
+	out := new(PlusGatewayEndpoint)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusList) DeepCopyInto(out *PlusList) {
+func (in *PlusGatewayGrpc) DeepCopyInto(out *PlusGatewayGrpc) {
+	*out = *in
+	if in.Services != nil {
+		in, out := &in.Services, &out.Services
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayGrpc.
+func (in *PlusGatewayGrpc) DeepCopy() *PlusGatewayGrpc {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayGrpc)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayJwt) DeepCopyInto(out *PlusGatewayJwt) {
+	*out = *in
+	if in.Audiences != nil {
+		in, out := &in.Audiences, &out.Audiences
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.JwksFrom != nil {
+		in, out := &in.JwksFrom, &out.JwksFrom
+		*out = new(corev1.ConfigMapKeySelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]*PlusGatewayJwtRule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusGatewayJwtRule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayJwt.
+func (in *PlusGatewayJwt) DeepCopy() *PlusGatewayJwt {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayJwt)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayJwtRule) DeepCopyInto(out *PlusGatewayJwtRule) {
+	*out = *in
+	if in.Paths != nil {
+		in, out := &in.Paths, &out.Paths
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Methods != nil {
+		in, out := &in.Methods, &out.Methods
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Claims != nil {
+		in, out := &in.Claims, &out.Claims
+		*out = make(map[string][]string, len(*in))
+		for key, val := range *in {
+			var outVal []string
+			if val == nil {
+				(*out)[key] = nil
+			} else {
+				in, out := &val, &outVal
+				*out = make([]string, len(*in))
+				copy(*out, *in)
+			}
+			(*out)[key] = outVal
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayJwtRule.
+func (in *PlusGatewayJwtRule) DeepCopy() *PlusGatewayJwtRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayJwtRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayListener) DeepCopyInto(out *PlusGatewayListener) {
+	*out = *in
+	if in.Hosts != nil {
+		in, out := &in.Hosts, &out.Hosts
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Gateways != nil {
+		in, out := &in.Gateways, &out.Gateways
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.PathPrefix != nil {
+		in, out := &in.PathPrefix, &out.PathPrefix
+		*out = new(string)
+		**out = **in
+	}
+	if in.Cors != nil {
+		in, out := &in.Cors, &out.Cors
+		*out = new(PlusGatewayCors)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayListener.
+func (in *PlusGatewayListener) DeepCopy() *PlusGatewayListener {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayListener)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayMaintenance) DeepCopyInto(out *PlusGatewayMaintenance) {
+	*out = *in
+	if in.AllowHeadersMatch != nil {
+		in, out := &in.AllowHeadersMatch, &out.AllowHeadersMatch
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.AllowSourceIPs != nil {
+		in, out := &in.AllowSourceIPs, &out.AllowSourceIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayMaintenance.
+func (in *PlusGatewayMaintenance) DeepCopy() *PlusGatewayMaintenance {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayMaintenance)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayRedirect) DeepCopyInto(out *PlusGatewayRedirect) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayRedirect.
+func (in *PlusGatewayRedirect) DeepCopy() *PlusGatewayRedirect {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayRedirect)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGatewayRoute) DeepCopyInto(out *PlusGatewayRoute) {
+	*out = *in
+	if in.HeadersMatch != nil {
+		in, out := &in.HeadersMatch, &out.HeadersMatch
+		*out = make([]map[string]string, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = make(map[string]string, len(*in))
+				for key, val := range *in {
+					(*out)[key] = val
+				}
+			}
+		}
+	}
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = new(PlusHeaders)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Cors != nil {
+		in, out := &in.Cors, &out.Cors
+		*out = new(PlusGatewayCors)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGatewayRoute.
+func (in *PlusGatewayRoute) DeepCopy() *PlusGatewayRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGatewayRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusGlobalRateLimit) DeepCopyInto(out *PlusGlobalRateLimit) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]*PlusRateLimitRule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusRateLimitRule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusGlobalRateLimit.
+func (in *PlusGlobalRateLimit) DeepCopy() *PlusGlobalRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusGlobalRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusHeaderOperations) DeepCopyInto(out *PlusHeaderOperations) {
+	*out = *in
+	if in.Set != nil {
+		in, out := &in.Set, &out.Set
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Add != nil {
+		in, out := &in.Add, &out.Add
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Remove != nil {
+		in, out := &in.Remove, &out.Remove
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusHeaderOperations.
+func (in *PlusHeaderOperations) DeepCopy() *PlusHeaderOperations {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusHeaderOperations)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusHeaders) DeepCopyInto(out *PlusHeaders) {
+	*out = *in
+	if in.Request != nil {
+		in, out := &in.Request, &out.Request
+		*out = new(PlusHeaderOperations)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Response != nil {
+		in, out := &in.Response, &out.Response
+		*out = new(PlusHeaderOperations)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusHeaders.
+func (in *PlusHeaders) DeepCopy() *PlusHeaders {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusHeaders)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusList) DeepCopyInto(out *PlusList) {
 	*out = *in
 	out.TypeMeta = in.TypeMeta
 	in.ListMeta.DeepCopyInto(&out.ListMeta)
@@ -292,115 +966,340 @@ func (in *PlusList) DeepCopyInto(out *PlusList) {
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusList.
-func (in *PlusList) DeepCopy() *PlusList {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusList.
+func (in *PlusList) DeepCopy() *PlusList {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *PlusList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicy) DeepCopyInto(out *PlusPolicy) {
+	*out = *in
+	if in.Retries != nil {
+		in, out := &in.Retries, &out.Retries
+		*out = new(PlusPolicyRetries)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Fault != nil {
+		in, out := &in.Fault, &out.Fault
+		*out = new(PlusPolicyFault)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.OutlierDetection != nil {
+		in, out := &in.OutlierDetection, &out.OutlierDetection
+		*out = new(PlusPolicyOutlierDetection)
+		**out = **in
+	}
+	if in.LoadBalancer != nil {
+		in, out := &in.LoadBalancer, &out.LoadBalancer
+		*out = new(PlusPolicyLoadBalancer)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ConnectionPool != nil {
+		in, out := &in.ConnectionPool, &out.ConnectionPool
+		*out = new(PlusPolicyConnectionPool)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(PlusPolicyRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Streaming != nil {
+		in, out := &in.Streaming, &out.Streaming
+		*out = new(PlusPolicyStreaming)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Locality != nil {
+		in, out := &in.Locality, &out.Locality
+		*out = new(PlusPolicyLocality)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicy.
+func (in *PlusPolicy) DeepCopy() *PlusPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyConnectionPool) DeepCopyInto(out *PlusPolicyConnectionPool) {
+	*out = *in
+	if in.Tcp != nil {
+		in, out := &in.Tcp, &out.Tcp
+		*out = new(PlusPolicyConnectionPoolTcp)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Http != nil {
+		in, out := &in.Http, &out.Http
+		*out = new(PlusPolicyConnectionPoolHttp)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyConnectionPool.
+func (in *PlusPolicyConnectionPool) DeepCopy() *PlusPolicyConnectionPool {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyConnectionPool)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyConnectionPoolHttp) DeepCopyInto(out *PlusPolicyConnectionPoolHttp) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyConnectionPoolHttp.
+func (in *PlusPolicyConnectionPoolHttp) DeepCopy() *PlusPolicyConnectionPoolHttp {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyConnectionPoolHttp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyConnectionPoolTcp) DeepCopyInto(out *PlusPolicyConnectionPoolTcp) {
+	*out = *in
+	if in.TcpKeepalive != nil {
+		in, out := &in.TcpKeepalive, &out.TcpKeepalive
+		*out = new(PlusPolicyTcpKeepalive)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyConnectionPoolTcp.
+func (in *PlusPolicyConnectionPoolTcp) DeepCopy() *PlusPolicyConnectionPoolTcp {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyConnectionPoolTcp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyFault) DeepCopyInto(out *PlusPolicyFault) {
+	*out = *in
+	if in.Delay != nil {
+		in, out := &in.Delay, &out.Delay
+		*out = new(PlusPolicyFaultDelay)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Abort != nil {
+		in, out := &in.Abort, &out.Abort
+		*out = new(PlusPolicyFaultAbort)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Versions != nil {
+		in, out := &in.Versions, &out.Versions
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.HeadersMatch != nil {
+		in, out := &in.HeadersMatch, &out.HeadersMatch
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.ExpiresAt != nil {
+		in, out := &in.ExpiresAt, &out.ExpiresAt
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFault.
+func (in *PlusPolicyFault) DeepCopy() *PlusPolicyFault {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyFault)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyFaultAbort) DeepCopyInto(out *PlusPolicyFaultAbort) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFaultAbort.
+func (in *PlusPolicyFaultAbort) DeepCopy() *PlusPolicyFaultAbort {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyFaultAbort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyFaultDelay) DeepCopyInto(out *PlusPolicyFaultDelay) {
+	*out = *in
+	if in.Percent != nil {
+		in, out := &in.Percent, &out.Percent
+		*out = new(int32)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFaultDelay.
+func (in *PlusPolicyFaultDelay) DeepCopy() *PlusPolicyFaultDelay {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusList)
+	out := new(PlusPolicyFaultDelay)
 	in.DeepCopyInto(out)
 	return out
 }
 
-// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
-func (in *PlusList) DeepCopyObject() runtime.Object {
-	if c := in.DeepCopy(); c != nil {
-		return c
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyHttpCookie) DeepCopyInto(out *PlusPolicyHttpCookie) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyHttpCookie.
+func (in *PlusPolicyHttpCookie) DeepCopy() *PlusPolicyHttpCookie {
+	if in == nil {
+		return nil
 	}
-	return nil
+	out := new(PlusPolicyHttpCookie)
+	in.DeepCopyInto(out)
+	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusPolicy) DeepCopyInto(out *PlusPolicy) {
+func (in *PlusPolicyLoadBalancer) DeepCopyInto(out *PlusPolicyLoadBalancer) {
 	*out = *in
-	if in.Retries != nil {
-		in, out := &in.Retries, &out.Retries
-		*out = new(PlusPolicyRetries)
-		**out = **in
-	}
-	if in.Fault != nil {
-		in, out := &in.Fault, &out.Fault
-		*out = new(PlusPolicyFault)
+	if in.ConsistentHash != nil {
+		in, out := &in.ConsistentHash, &out.ConsistentHash
+		*out = new(PlusPolicyLoadBalancerConsistentHash)
 		(*in).DeepCopyInto(*out)
 	}
-	if in.OutlierDetection != nil {
-		in, out := &in.OutlierDetection, &out.OutlierDetection
-		*out = new(PlusPolicyOutlierDetection)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyLoadBalancer.
+func (in *PlusPolicyLoadBalancer) DeepCopy() *PlusPolicyLoadBalancer {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyLoadBalancer)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyLoadBalancerConsistentHash) DeepCopyInto(out *PlusPolicyLoadBalancerConsistentHash) {
+	*out = *in
+	if in.HttpCookie != nil {
+		in, out := &in.HttpCookie, &out.HttpCookie
+		*out = new(PlusPolicyHttpCookie)
 		**out = **in
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicy.
-func (in *PlusPolicy) DeepCopy() *PlusPolicy {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyLoadBalancerConsistentHash.
+func (in *PlusPolicyLoadBalancerConsistentHash) DeepCopy() *PlusPolicyLoadBalancerConsistentHash {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusPolicy)
+	out := new(PlusPolicyLoadBalancerConsistentHash)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusPolicyFault) DeepCopyInto(out *PlusPolicyFault) {
+func (in *PlusPolicyLocality) DeepCopyInto(out *PlusPolicyLocality) {
 	*out = *in
-	if in.Delay != nil {
-		in, out := &in.Delay, &out.Delay
-		*out = new(PlusPolicyFaultDelay)
-		(*in).DeepCopyInto(*out)
+	if in.FailoverPriority != nil {
+		in, out := &in.FailoverPriority, &out.FailoverPriority
+		*out = make([]string, len(*in))
+		copy(*out, *in)
 	}
-	if in.Abort != nil {
-		in, out := &in.Abort, &out.Abort
-		*out = new(PlusPolicyFaultAbort)
-		(*in).DeepCopyInto(*out)
+	if in.Failover != nil {
+		in, out := &in.Failover, &out.Failover
+		*out = make([]PlusPolicyLocalityFailover, len(*in))
+		copy(*out, *in)
+	}
+	if in.Distribute != nil {
+		in, out := &in.Distribute, &out.Distribute
+		*out = make([]PlusPolicyLocalityDistribute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFault.
-func (in *PlusPolicyFault) DeepCopy() *PlusPolicyFault {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyLocality.
+func (in *PlusPolicyLocality) DeepCopy() *PlusPolicyLocality {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusPolicyFault)
+	out := new(PlusPolicyLocality)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusPolicyFaultAbort) DeepCopyInto(out *PlusPolicyFaultAbort) {
+func (in *PlusPolicyLocalityDistribute) DeepCopyInto(out *PlusPolicyLocalityDistribute) {
 	*out = *in
-	if in.Percent != nil {
-		in, out := &in.Percent, &out.Percent
-		*out = new(int32)
-		**out = **in
+	if in.To != nil {
+		in, out := &in.To, &out.To
+		*out = make(map[string]uint32, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
 	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFaultAbort.
-func (in *PlusPolicyFaultAbort) DeepCopy() *PlusPolicyFaultAbort {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyLocalityDistribute.
+func (in *PlusPolicyLocalityDistribute) DeepCopy() *PlusPolicyLocalityDistribute {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusPolicyFaultAbort)
+	out := new(PlusPolicyLocalityDistribute)
 	in.DeepCopyInto(out)
 	return out
 }
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
-func (in *PlusPolicyFaultDelay) DeepCopyInto(out *PlusPolicyFaultDelay) {
+func (in *PlusPolicyLocalityFailover) DeepCopyInto(out *PlusPolicyLocalityFailover) {
 	*out = *in
-	if in.Percent != nil {
-		in, out := &in.Percent, &out.Percent
-		*out = new(int32)
-		**out = **in
-	}
 }
 
-// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyFaultDelay.
-func (in *PlusPolicyFaultDelay) DeepCopy() *PlusPolicyFaultDelay {
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyLocalityFailover.
+func (in *PlusPolicyLocalityFailover) DeepCopy() *PlusPolicyLocalityFailover {
 	if in == nil {
 		return nil
 	}
-	out := new(PlusPolicyFaultDelay)
+	out := new(PlusPolicyLocalityFailover)
 	in.DeepCopyInto(out)
 	return out
 }
@@ -420,9 +1319,45 @@ func (in *PlusPolicyOutlierDetection) DeepCopy() *PlusPolicyOutlierDetection {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyRateLimit) DeepCopyInto(out *PlusPolicyRateLimit) {
+	*out = *in
+	if in.Local != nil {
+		in, out := &in.Local, &out.Local
+		*out = make([]*PlusRateLimitRule, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusRateLimitRule)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.Global != nil {
+		in, out := &in.Global, &out.Global
+		*out = new(PlusGlobalRateLimit)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyRateLimit.
+func (in *PlusPolicyRateLimit) DeepCopy() *PlusPolicyRateLimit {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyRateLimit)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusPolicyRetries) DeepCopyInto(out *PlusPolicyRetries) {
 	*out = *in
+	if in.RetryRemoteLocalities != nil {
+		in, out := &in.RetryRemoteLocalities, &out.RetryRemoteLocalities
+		*out = new(bool)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyRetries.
@@ -435,6 +1370,63 @@ func (in *PlusPolicyRetries) DeepCopy() *PlusPolicyRetries {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyStreaming) DeepCopyInto(out *PlusPolicyStreaming) {
+	*out = *in
+	if in.TcpKeepalive != nil {
+		in, out := &in.TcpKeepalive, &out.TcpKeepalive
+		*out = new(PlusPolicyTcpKeepalive)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyStreaming.
+func (in *PlusPolicyStreaming) DeepCopy() *PlusPolicyStreaming {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyStreaming)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusPolicyTcpKeepalive) DeepCopyInto(out *PlusPolicyTcpKeepalive) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusPolicyTcpKeepalive.
+func (in *PlusPolicyTcpKeepalive) DeepCopy() *PlusPolicyTcpKeepalive {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusPolicyTcpKeepalive)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusRateLimitRule) DeepCopyInto(out *PlusRateLimitRule) {
+	*out = *in
+	if in.Headers != nil {
+		in, out := &in.Headers, &out.Headers
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusRateLimitRule.
+func (in *PlusRateLimitRule) DeepCopy() *PlusRateLimitRule {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusRateLimitRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusScale) DeepCopyInto(out *PlusScale) {
 	*out = *in
@@ -450,6 +1442,55 @@ func (in *PlusScale) DeepCopy() *PlusScale {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusService) DeepCopyInto(out *PlusService) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.LoadBalancerSourceRanges != nil {
+		in, out := &in.LoadBalancerSourceRanges, &out.LoadBalancerSourceRanges
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusService.
+func (in *PlusService) DeepCopy() *PlusService {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusService)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *PlusServiceAccount) DeepCopyInto(out *PlusServiceAccount) {
+	*out = *in
+	if in.Annotations != nil {
+		in, out := &in.Annotations, &out.Annotations
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusServiceAccount.
+func (in *PlusServiceAccount) DeepCopy() *PlusServiceAccount {
+	if in == nil {
+		return nil
+	}
+	out := new(PlusServiceAccount)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *PlusSpec) DeepCopyInto(out *PlusSpec) {
 	*out = *in
@@ -474,6 +1515,38 @@ func (in *PlusSpec) DeepCopyInto(out *PlusSpec) {
 			}
 		}
 	}
+	if in.Access != nil {
+		in, out := &in.Access, &out.Access
+		*out = new(PlusAccess)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Service != nil {
+		in, out := &in.Service, &out.Service
+		*out = new(PlusService)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Egress != nil {
+		in, out := &in.Egress, &out.Egress
+		*out = make([]*PlusEgress, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusEgress)
+				(*in).DeepCopyInto(*out)
+			}
+		}
+	}
+	if in.DependsOn != nil {
+		in, out := &in.DependsOn, &out.DependsOn
+		*out = make([]*PlusDependency, len(*in))
+		for i := range *in {
+			if (*in)[i] != nil {
+				in, out := &(*in)[i], &(*out)[i]
+				*out = new(PlusDependency)
+				**out = **in
+			}
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusSpec.
@@ -497,6 +1570,30 @@ func (in *PlusStatus) DeepCopyInto(out *PlusStatus) {
 		}
 	}
 	out.Desc = in.Desc
+	if in.Fault != nil {
+		in, out := &in.Fault, &out.Fault
+		*out = new(PlusFaultStatus)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.URLs != nil {
+		in, out := &in.URLs, &out.URLs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Aliases != nil {
+		in, out := &in.Aliases, &out.Aliases
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new PlusStatus.
@@ -508,3 +1605,60 @@ func (in *PlusStatus) DeepCopy() *PlusStatus {
 	in.DeepCopyInto(out)
 	return out
 }
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitDescriptor) DeepCopyInto(out *RateLimitDescriptor) {
+	*out = *in
+	if in.RateLimit != nil {
+		in, out := &in.RateLimit, &out.RateLimit
+		*out = new(RateLimitServiceUnit)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitDescriptor.
+func (in *RateLimitDescriptor) DeepCopy() *RateLimitDescriptor {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitDescriptor)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitServiceConfig) DeepCopyInto(out *RateLimitServiceConfig) {
+	*out = *in
+	if in.Descriptors != nil {
+		in, out := &in.Descriptors, &out.Descriptors
+		*out = make([]RateLimitDescriptor, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitServiceConfig.
+func (in *RateLimitServiceConfig) DeepCopy() *RateLimitServiceConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitServiceConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *RateLimitServiceUnit) DeepCopyInto(out *RateLimitServiceUnit) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new RateLimitServiceUnit.
+func (in *RateLimitServiceUnit) DeepCopy() *RateLimitServiceUnit {
+	if in == nil {
+		return nil
+	}
+	out := new(RateLimitServiceUnit)
+	in.DeepCopyInto(out)
+	return out
+}
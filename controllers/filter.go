@@ -1,6 +1,10 @@
 package controllers
 
 import (
+	plusappsv1 "clusterplus.io/clusterplus/api/v1"
+	corev1 "k8s.io/api/core/v1"
+	"reflect"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/event"
 )
 
@@ -26,3 +30,88 @@ func (f EventFilter) Update(e event.UpdateEvent) bool {
 func (f EventFilter) Generic(e event.GenericEvent) bool {
 	return true
 }
+
+// ReadyChangedFilter 只处理 Plus 的创建、删除和 Ready 状态变化，用于通知依赖方
+type ReadyChangedFilter struct {
+}
+
+func (f ReadyChangedFilter) Create(e event.CreateEvent) bool {
+	return true
+}
+
+func (f ReadyChangedFilter) Delete(e event.DeleteEvent) bool {
+	return true
+}
+
+func (f ReadyChangedFilter) Update(e event.UpdateEvent) bool {
+	oldPlus, ok := e.ObjectOld.(*plusappsv1.Plus)
+	if !ok {
+		return false
+	}
+	newPlus, ok := e.ObjectNew.(*plusappsv1.Plus)
+	if !ok {
+		return false
+	}
+	return oldPlus.IsReady() != newPlus.IsReady()
+}
+
+func (f ReadyChangedFilter) Generic(e event.GenericEvent) bool {
+	return false
+}
+
+// ServiceAccountChangedFilter 只处理 Plus 的创建、删除和身份变化，用于更新 allowFrom 引用它的访问策略
+type ServiceAccountChangedFilter struct {
+}
+
+func (f ServiceAccountChangedFilter) Create(e event.CreateEvent) bool {
+	return true
+}
+
+func (f ServiceAccountChangedFilter) Delete(e event.DeleteEvent) bool {
+	return true
+}
+
+func (f ServiceAccountChangedFilter) Update(e event.UpdateEvent) bool {
+	oldPlus, ok := e.ObjectOld.(*plusappsv1.Plus)
+	if !ok {
+		return false
+	}
+	newPlus, ok := e.ObjectNew.(*plusappsv1.Plus)
+	if !ok {
+		return false
+	}
+	return !reflect.DeepEqual(oldPlus.GeneratePrincipals(), newPlus.GeneratePrincipals())
+}
+
+func (f ServiceAccountChangedFilter) Generic(e event.GenericEvent) bool {
+	return false
+}
+
+// ReadinessGateFilter 只处理带有依赖 readinessGate 并且还没有设置为 True 的 Pod，用于新 Pod 创建后设置 condition
+type ReadinessGateFilter struct {
+}
+
+func (f ReadinessGateFilter) isPending(obj client.Object) bool {
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return false
+	}
+	return hasReadinessGate(pod, plusappsv1.DependenciesReadinessGate) &&
+		!isPodConditionTrue(pod, corev1.PodConditionType(plusappsv1.DependenciesReadinessGate))
+}
+
+func (f ReadinessGateFilter) Create(e event.CreateEvent) bool {
+	return f.isPending(e.Object)
+}
+
+func (f ReadinessGateFilter) Delete(e event.DeleteEvent) bool {
+	return false
+}
+
+func (f ReadinessGateFilter) Update(e event.UpdateEvent) bool {
+	return false
+}
+
+func (f ReadinessGateFilter) Generic(e event.GenericEvent) bool {
+	return false
+}
@@ -1,5 +1,10 @@
 package controllers
 
+import (
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
@@ -18,3 +23,44 @@ func removeString(slice []string, s string) (result []string) {
 	}
 	return
 }
+
+func hasReadinessGate(pod *corev1.Pod, conditionType string) bool {
+	for _, gate := range pod.Spec.ReadinessGates {
+		if string(gate.ConditionType) == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// isPodConditionTrue Pod 的 condition 是否为 True
+func isPodConditionTrue(pod *corev1.Pod, conditionType corev1.PodConditionType) bool {
+	for _, condition := range pod.Status.Conditions {
+		if condition.Type == conditionType {
+			return condition.Status == corev1.ConditionTrue
+		}
+	}
+	return false
+}
+
+// setPodCondition 设置 Pod 的 condition，返回是否有变化
+func setPodCondition(pod *corev1.Pod, conditionType corev1.PodConditionType, status corev1.ConditionStatus) bool {
+	for i := range pod.Status.Conditions {
+		condition := &pod.Status.Conditions[i]
+		if condition.Type != conditionType {
+			continue
+		}
+		if condition.Status == status {
+			return false
+		}
+		condition.Status = status
+		condition.LastTransitionTime = metav1.Now()
+		return true
+	}
+	pod.Status.Conditions = append(pod.Status.Conditions, corev1.PodCondition{
+		Type:               conditionType,
+		Status:             status,
+		LastTransitionTime: metav1.Now(),
+	})
+	return true
+}
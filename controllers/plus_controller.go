@@ -26,16 +26,29 @@ import (
 	"github.com/go-logr/logr"
 	"github.com/spf13/viper"
 	istioclientapiv1 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	istiosecurityclientv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"reflect"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
+	"strings"
+	"time"
 )
 
 // PlusReconciler reconciles a Plus object
@@ -103,6 +116,21 @@ func (r *PlusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	instance := found.DeepCopy()
 	instance.Status.Success = true
 
+	// 故障注入到期后不再下发，到期时间点重新调和
+	requeueAfter := instance.UpdateFaultStatus(time.Now())
+	if instance.IsFaultExpired() {
+		if err := r.removeExpiredFault(ctx, &found, instance.Status.Fault); err != nil {
+			if apierrors.IsConflict(err) {
+				return ctrl.Result{Requeue: true}, nil
+			}
+			log.Error(err, "Remove Expired Fault Error")
+			return ctrl.Result{}, err
+		}
+		r.Recorder.Event(instance, "Normal", "FaultExpired", fmt.Sprintf("fault injection expired at %s", instance.Status.Fault.ExpiresAt))
+		// spec 变化后会重新调和
+		return ctrl.Result{}, nil
+	}
+
 	// 创建或更新操作
 	resources, err := r.getOwnResources(instance, log)
 	if err != nil {
@@ -125,6 +153,16 @@ func (r *PlusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 		}
 	}
 
+	if err := r.updateConflictCondition(ctx, instance); err != nil {
+		log.Error(err, "Update Conflict Condition Error")
+	}
+
+	r.recordRolloutStuck(&found, instance)
+	r.updateReadyCondition(instance)
+	if err := r.updateDependenciesCondition(ctx, instance); err != nil {
+		log.Error(err, "Update Dependencies Condition Error")
+	}
+
 	instance.GenerateStatusDesc()
 	if !reflect.DeepEqual(instance.Status, found.Status) {
 		if err := r.Status().Update(context.Background(), instance); err != nil {
@@ -142,8 +180,209 @@ func (r *PlusReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 
 	log.Info("Successfully Reconciled")
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+
+}
+
+// removeExpiredFault 从 spec 中移除过期的故障注入，status 中记录实验窗口，避免之后修改 expiresAt 或 duration 时重新启用旧的实验
+func (r *PlusReconciler) removeExpiredFault(ctx context.Context, found *plusappsv1.Plus, fault *plusappsv1.PlusFaultStatus) error {
+	updated := found.DeepCopy()
+	updated.Spec.Policy.Fault = nil
+	if err := r.Update(ctx, updated); err != nil {
+		return err
+	}
+	updated.Status.Fault = fault.DeepCopy()
+	updated.Status.Fault.Hash = ""
+	return r.Status().Update(ctx, updated)
+}
+
+// updateConflictCondition 检查和其他 Plus 的域名和路径前缀冲突，webhook 生效前已经存在的冲突只能在这里发现
+func (r *PlusReconciler) updateConflictCondition(ctx context.Context, instance *plusappsv1.Plus) error {
+	conflicts, err := instance.ListConflicts(ctx, r.Client)
+	if err != nil {
+		return err
+	}
 
+	condition := metav1.Condition{
+		Type:               plusappsv1.ConditionConflict,
+		Status:             metav1.ConditionFalse,
+		Reason:             "NoConflict",
+		ObservedGeneration: instance.GetGeneration(),
+	}
+	if len(conflicts) > 0 {
+		condition.Status = metav1.ConditionTrue
+		condition.Reason = "HostPrefixOverlap"
+		condition.Message = strings.Join(conflicts, "; ")
+		if !meta.IsStatusConditionTrue(instance.Status.Conditions, plusappsv1.ConditionConflict) {
+			r.Recorder.Event(instance, "Warning", "Conflict", condition.Message)
+		}
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	return nil
+}
+
+// recordRolloutStuck Deployment 更新超时只在 condition 变化时记录事件，避免每次调和都记录
+func (r *PlusReconciler) recordRolloutStuck(found, instance *plusappsv1.Plus) {
+	condition := meta.FindStatusCondition(instance.Status.Conditions, plusappsv1.ConditionRolloutStuck)
+	if condition == nil || condition.Status != metav1.ConditionTrue {
+		return
+	}
+	if previous := meta.FindStatusCondition(found.Status.Conditions, plusappsv1.ConditionRolloutStuck); previous != nil &&
+		previous.Status == metav1.ConditionTrue && previous.Message == condition.Message {
+		return
+	}
+	r.Recorder.Event(instance, "Warning", plusappsv1.ReasonProgressDeadlineExceeded, condition.Message)
+}
+
+// updateReadyCondition 依赖该 Plus 的 Pod 等待 Ready 状态
+func (r *PlusReconciler) updateReadyCondition(instance *plusappsv1.Plus) {
+	condition := metav1.Condition{
+		Type:               plusappsv1.ConditionReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "Available",
+		ObservedGeneration: instance.GetGeneration(),
+	}
+	if !instance.IsAvailable() {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "NotAvailable"
+		condition.Message = "waiting for available replicas"
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+}
+
+// updateDependenciesCondition 检查 waitReady 的依赖是否 Ready，并同步到 Pod 的 readinessGate。
+// 只在依赖第一次全部 Ready 之前等待，之后依赖不可用也不会改回 False，避免依赖抖动时摘除所有 Pod
+func (r *PlusReconciler) updateDependenciesCondition(ctx context.Context, instance *plusappsv1.Plus) error {
+	if !instance.IsWaitReady() {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, plusappsv1.ConditionDependenciesReady)
+		return nil
+	}
+
+	if meta.IsStatusConditionTrue(instance.Status.Conditions, plusappsv1.ConditionDependenciesReady) {
+		return r.updatePodReadinessGates(ctx, instance, corev1.ConditionTrue)
+	}
+
+	notReady, err := instance.ListNotReadyDependencies(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+
+	condition := metav1.Condition{
+		Type:               plusappsv1.ConditionDependenciesReady,
+		Status:             metav1.ConditionTrue,
+		Reason:             "DependenciesReady",
+		ObservedGeneration: instance.GetGeneration(),
+	}
+	if len(notReady) > 0 {
+		condition.Status = metav1.ConditionFalse
+		condition.Reason = "DependenciesNotReady"
+		condition.Message = fmt.Sprintf("waiting for %s", strings.Join(notReady, ", "))
+	} else if meta.IsStatusConditionFalse(instance.Status.Conditions, plusappsv1.ConditionDependenciesReady) {
+		r.Recorder.Event(instance, "Normal", "DependenciesReady", "all dependencies are ready")
+	}
+	meta.SetStatusCondition(&instance.Status.Conditions, condition)
+	return r.updatePodReadinessGates(ctx, instance, corev1.ConditionStatus(condition.Status))
+}
+
+// updatePodReadinessGates 设置 Pod 的 readinessGate condition，已经是 True 的 Pod 不再修改。
+// 新创建的 Pod 通过 findGatedPodPlus 触发调和后设置，缓存中只有带 DependenciesReadinessGateLabel 的 Pod
+func (r *PlusReconciler) updatePodReadinessGates(ctx context.Context, instance *plusappsv1.Plus, status corev1.ConditionStatus) error {
+	pods := &corev1.PodList{}
+	if err := r.List(ctx, pods, client.InNamespace(instance.GetNamespace()), client.MatchingLabels{"plus": instance.GetName()}); err != nil {
+		return err
+	}
+	conditionType := corev1.PodConditionType(plusappsv1.DependenciesReadinessGate)
+	for i := range pods.Items {
+		pod := &pods.Items[i]
+		if !hasReadinessGate(pod, plusappsv1.DependenciesReadinessGate) || isPodConditionTrue(pod, conditionType) {
+			continue
+		}
+		if !setPodCondition(pod, conditionType, status) {
+			continue
+		}
+		if err := r.Status().Update(ctx, pod); err != nil && !apierrors.IsNotFound(err) {
+			return err
+		}
+	}
+	return nil
+}
+
+// findGatedPodPlus 带有依赖 readinessGate 的 Pod 创建后，需要调和所属的 Plus 设置 condition
+func (r *PlusReconciler) findGatedPodPlus(obj client.Object) []reconcile.Request {
+	name := obj.GetLabels()["plus"]
+	if name == "" {
+		return nil
+	}
+	return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}}}
+}
+
+// findDependents 一个 Plus 的 Ready 状态变化后，等待它的 Plus 需要重新调和
+func (r *PlusReconciler) findDependents(obj client.Object) []reconcile.Request {
+	list := &plusappsv1.PlusList{}
+	key := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	if err := r.List(context.TODO(), list, client.MatchingFields{plusappsv1.DependsOnField: key}); err != nil {
+		r.log.Error(err, "List Plus by dependency error", "Plus", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.GetName(), Namespace: item.GetNamespace()}})
+	}
+	return requests
+}
+
+// findAllowingPluses 一个 Plus 的 ServiceAccount 变化后，在 allowFrom 中引用它的 Plus 需要更新访问策略
+func (r *PlusReconciler) findAllowingPluses(obj client.Object) []reconcile.Request {
+	list := &plusappsv1.PlusList{}
+	key := fmt.Sprintf("%s/%s", obj.GetNamespace(), obj.GetName())
+	if err := r.List(context.TODO(), list, client.MatchingFields{plusappsv1.AllowFromPlusesField: key}); err != nil {
+		r.log.Error(err, "List Plus by allowFrom error", "Plus", key)
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.GetName(), Namespace: item.GetNamespace()}})
+	}
+	return requests
+}
+
+// findJwksConsumers jwks ConfigMap 变化后，引用它的 Plus 需要重新生成 RequestAuthentication
+func (r *PlusReconciler) findJwksConsumers(obj client.Object) []reconcile.Request {
+	list := &plusappsv1.PlusList{}
+	if err := r.List(context.TODO(), list, client.InNamespace(obj.GetNamespace()),
+		client.MatchingFields{plusappsv1.JwksConfigMapField: obj.GetName()}); err != nil {
+		r.log.Error(err, "List Plus by jwks ConfigMap error", "ConfigMap", obj.GetName())
+		return nil
+	}
+	requests := make([]reconcile.Request, 0, len(list.Items))
+	for _, item := range list.Items {
+		requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.GetName(), Namespace: item.GetNamespace()}})
+	}
+	return requests
+}
+
+// findConflictCandidates 一个 Plus 变化后，使用相同域名的 Plus 需要重新检查冲突。
+// 更新事件中 EnqueueRequestsFromMapFunc 会分别对 ObjectOld 和 ObjectNew 调用，修改域名后旧域名上的 Plus 也会重新检查
+func (r *PlusReconciler) findConflictCandidates(obj client.Object) []reconcile.Request {
+	plus, ok := obj.(*plusappsv1.Plus)
+	if !ok {
+		return nil
+	}
+	requests := make([]reconcile.Request, 0)
+	for _, host := range plus.GetGatewayHosts() {
+		list := &plusappsv1.PlusList{}
+		if err := r.List(context.TODO(), list, client.MatchingFields{plusappsv1.GatewayHostsField: host}); err != nil {
+			r.log.Error(err, "List Plus by host error", "Host", host)
+			continue
+		}
+		for _, item := range list.Items {
+			if item.GetNamespace() == plus.GetNamespace() && item.GetName() == plus.GetName() {
+				continue
+			}
+			requests = append(requests, reconcile.Request{NamespacedName: types.NamespacedName{Name: item.GetName(), Namespace: item.GetNamespace()}})
+		}
+	}
+	return requests
 }
 
 func (r *PlusReconciler) Finalizer(ctx context.Context, log logr.Logger, instance *plusappsv1.Plus) (res ctrl.Result, err error, isContinue bool) {
@@ -192,11 +431,20 @@ func (r *PlusReconciler) Finalizer(ctx context.Context, log logr.Logger, instanc
 // 根据Unit.Spec生成其所有的own resource
 func (r *PlusReconciler) getOwnResources(instance *plusappsv1.Plus, log logr.Logger) ([]IResource, error) {
 	var resources []IResource
+	resources = append(resources, ownv1.NewServiceAccount(instance, r.Scheme, r.Client, log))
 	resources = append(resources, ownv1.NewDeployment(instance, r.Scheme, r.Client, log))
 	resources = append(resources, ownv1.NewService(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewServiceEntry(instance, r.Scheme, r.Client, log))
 	resources = append(resources, ownv1.NewDestinationRule(instance, r.Scheme, r.Client, log))
 	resources = append(resources, ownv1.NewVirtualService(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewRootVirtualService(instance, r.Scheme, r.Client, log))
 	resources = append(resources, ownv1.NewAutoScaling(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewRequestAuthentication(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewAuthorizationPolicy(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewPeerAuthentication(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewEnvoyFilter(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewSidecar(instance, r.Scheme, r.Client, log))
+	resources = append(resources, ownv1.NewRateLimitConfig(instance, r.Scheme, r.Client, log))
 	return resources, nil
 }
 
@@ -204,7 +452,8 @@ func (r *PlusReconciler) PreDelete(instance *plusappsv1.Plus) error {
 	// 特别说明，own resource加上了ControllerReference之后，owner resource gc删除前，会先自动删除它的所有
 	// own resources，因此绑定ControllerReference后无需再特别处理删除own resource。
 	// 这里留空出来，是为了如果有自定义的pre delete逻辑的需要，可在这里实现。
-	return nil
+	// 根 VirtualService 没有 ControllerReference，需要移除该 Plus 的委托路由
+	return ownv1.NewRootVirtualService(instance, r.Scheme, r.Client, r.log).Apply()
 }
 
 func (r *PlusReconciler) LoadConfig() error {
@@ -235,6 +484,23 @@ func (r *PlusReconciler) LoadConfig() error {
 	return nil
 }
 
+// CacheSelectors 限制 manager 缓存的对象，Pod 只缓存带有依赖 readinessGate 的，避免缓存集群中所有的 Pod。
+// ConfigMap 只缓存限流配置和 jwksFrom 引用的，读取 ConfigMap 不经过缓存，见 CacheDisabledObjects
+func CacheSelectors() cache.SelectorsByObject {
+	plus, _ := labels.NewRequirement("plus", selection.Exists, nil)
+	gated, _ := labels.NewRequirement(plusappsv1.DependenciesReadinessGateLabel, selection.Exists, nil)
+	configMap, _ := labels.NewRequirement(plusappsv1.ConfigMapLabel, selection.Exists, nil)
+	return cache.SelectorsByObject{
+		&corev1.Pod{}:       {Label: labels.NewSelector().Add(*plus, *gated)},
+		&corev1.ConfigMap{}: {Label: labels.NewSelector().Add(*configMap)},
+	}
+}
+
+// CacheDisabledObjects 直接从 apiserver 读取的对象，还没有添加 ConfigMapLabel 的 ConfigMap 不在缓存中
+func CacheDisabledObjects() []client.Object {
+	return []client.Object{&corev1.ConfigMap{}}
+}
+
 // SetupWithManager sets up the controller with the Manager.
 func (r *PlusReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	r.log = ctrl.Log.WithName("controllers").WithName("Plus")
@@ -248,10 +514,27 @@ func (r *PlusReconciler) SetupWithManager(mgr ctrl.Manager) error {
 		For(&plusappsv1.Plus{}).
 		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ServiceAccount{}).
 		// Watch HPA 资源会导致频繁调和
 		//Owns(&autoscalingv1.HorizontalPodAutoscaler{}).
 		Owns(&istioclientapiv1.VirtualService{}).
 		Owns(&istioclientapiv1.DestinationRule{}).
+		Owns(&istioclientapiv1.ServiceEntry{}).
+		Owns(&istioclientapiv1.Sidecar{}).
+		Owns(&istiosecurityclientv1beta1.RequestAuthentication{}).
+		Owns(&istiosecurityclientv1beta1.AuthorizationPolicy{}).
+		Owns(&istiosecurityclientv1beta1.PeerAuthentication{}).
+		Owns(&istioclientapiv1.EnvoyFilter{}).
+		Owns(&corev1.ConfigMap{}).
+		Watches(&source.Kind{Type: &plusappsv1.Plus{}}, handler.EnqueueRequestsFromMapFunc(r.findConflictCandidates),
+			builder.WithPredicates(predicate.GenerationChangedPredicate{})).
+		Watches(&source.Kind{Type: &plusappsv1.Plus{}}, handler.EnqueueRequestsFromMapFunc(r.findDependents),
+			builder.WithPredicates(&ReadyChangedFilter{})).
+		Watches(&source.Kind{Type: &plusappsv1.Plus{}}, handler.EnqueueRequestsFromMapFunc(r.findAllowingPluses),
+			builder.WithPredicates(&ServiceAccountChangedFilter{})).
+		Watches(&source.Kind{Type: &corev1.ConfigMap{}}, handler.EnqueueRequestsFromMapFunc(r.findJwksConsumers)).
+		Watches(&source.Kind{Type: &corev1.Pod{}}, handler.EnqueueRequestsFromMapFunc(r.findGatedPodPlus),
+			builder.WithPredicates(&ReadinessGateFilter{})).
 		WithOptions(controller.Options{
 			MaxConcurrentReconciles: 10,
 		}).
@@ -20,6 +20,7 @@ import (
 	"flag"
 	"go.uber.org/zap/zapcore"
 	"istio.io/client-go/pkg/apis/networking/v1alpha3"
+	securityv1beta1 "istio.io/client-go/pkg/apis/security/v1beta1"
 	"os"
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -30,6 +31,7 @@ import (
 	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
 	_ "k8s.io/client-go/plugin/pkg/client/auth"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/healthz"
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 	//+kubebuilder:scaffold:imports
@@ -46,6 +48,8 @@ func init() {
 	utilruntime.Must(appsv1.AddToScheme(scheme))
 
 	utilruntime.Must(v1alpha3.AddToScheme(scheme))
+
+	utilruntime.Must(securityv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
@@ -74,6 +78,9 @@ func main() {
 		HealthProbeBindAddress: probeAddr,
 		LeaderElection:         enableLeaderElection,
 		LeaderElectionID:       "f964c980.clusterplus.io",
+		// 只缓存 controller 需要的 Pod 和 ConfigMap
+		NewCache:              cache.BuilderWithOptions(cache.Options{SelectorsByObject: controllers.CacheSelectors()}),
+		ClientDisableCacheFor: controllers.CacheDisabledObjects(),
 		// LeaderElectionReleaseOnCancel defines if the leader should step down voluntarily
 		// when the Manager ends. This requires the binary to immediately end when the
 		// Manager is stopped, otherwise, this setting is unsafe. Setting this significantly
@@ -91,6 +98,30 @@ func main() {
 		os.Exit(1)
 	}
 
+	// 根据网关域名查找 Plus，用于检查域名和路径前缀冲突
+	if err = appsv1.SetupGatewayHostsIndex(mgr); err != nil {
+		setupLog.Error(err, "unable to create field index", "field", appsv1.GatewayHostsField)
+		os.Exit(1)
+	}
+
+	// 根据依赖查找 Plus，依赖 Ready 后通知等待的 Plus
+	if err = appsv1.SetupDependsOnIndex(mgr); err != nil {
+		setupLog.Error(err, "unable to create field index", "field", appsv1.DependsOnField)
+		os.Exit(1)
+	}
+
+	// 根据 allowFrom 查找 Plus，被允许的 Plus 身份变化后更新访问策略
+	if err = appsv1.SetupAllowFromPlusesIndex(mgr); err != nil {
+		setupLog.Error(err, "unable to create field index", "field", appsv1.AllowFromPlusesField)
+		os.Exit(1)
+	}
+
+	// 根据 jwksFrom 查找 Plus，ConfigMap 变化后重新生成 RequestAuthentication
+	if err = appsv1.SetupJwksConfigMapIndex(mgr); err != nil {
+		setupLog.Error(err, "unable to create field index", "field", appsv1.JwksConfigMapField)
+		os.Exit(1)
+	}
+
 	if err = (&controllers.PlusReconciler{
 		Client:   mgr.GetClient(),
 		Scheme:   mgr.GetScheme(),